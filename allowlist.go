@@ -0,0 +1,231 @@
+// Package paymentwall provides a Go SDK for interacting with the Paymentwall APIs.
+package paymentwall
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// defaultIPAllowlistEntries are the addresses Paymentwall sends pingbacks from.
+var defaultIPAllowlistEntries = []string{
+	"174.36.92.186",
+	"174.36.96.66",
+	"174.36.92.187",
+	"174.36.92.192",
+	"174.37.14.28",
+	"216.127.71.0/24",
+}
+
+var defaultIPAllowlist = mustIPAllowlist(defaultIPAllowlistEntries...)
+
+// IPAllowlistSource supplies the allowlist used to check pingback source IPs.
+// Both *IPAllowlist and *IPAllowlistLoader implement it.
+type IPAllowlistSource interface {
+	Allowlist() *IPAllowlist
+}
+
+// IPAllowlist is an immutable set of IPv4 and IPv6 networks.
+type IPAllowlist struct {
+	nets []*net.IPNet
+}
+
+// NewIPAllowlist builds an allowlist from single addresses ("174.36.92.186",
+// "2001:db8::1") and CIDR ranges ("216.127.71.0/24", "2001:db8::/32").
+func NewIPAllowlist(entries ...string) (*IPAllowlist, error) {
+	a := &IPAllowlist{nets: make([]*net.IPNet, 0, len(entries))}
+	for _, e := range entries {
+		n, err := parseIPNet(e)
+		if err != nil {
+			return nil, err
+		}
+		a.nets = append(a.nets, n)
+	}
+	return a, nil
+}
+
+// ParseIPAllowlist reads one address or CIDR range per line. Blank lines and
+// text after "#" are ignored. An input without entries is an error, so that a
+// truncated file cannot lock out every pingback.
+func ParseIPAllowlist(r io.Reader) (*IPAllowlist, error) {
+	var entries []string
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line := sc.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		if line = strings.TrimSpace(line); line != "" {
+			entries = append(entries, line)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, errors.New("IP allowlist has no entries")
+	}
+	return NewIPAllowlist(entries...)
+}
+
+// DefaultIPAllowlist returns Paymentwall's published pingback addresses.
+func DefaultIPAllowlist() *IPAllowlist {
+	return defaultIPAllowlist
+}
+
+// mustIPAllowlist is like NewIPAllowlist but panics on invalid entries.
+func mustIPAllowlist(entries ...string) *IPAllowlist {
+	a, err := NewIPAllowlist(entries...)
+	if err != nil {
+		panic(err)
+	}
+	return a
+}
+
+// parseIPNet parses a CIDR range or a single address as a /32 or /128 network.
+func parseIPNet(s string) (*net.IPNet, error) {
+	s = strings.TrimSpace(s)
+	if strings.Contains(s, "/") {
+		_, n, err := net.ParseCIDR(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR %q: %w", s, err)
+		}
+		return n, nil
+	}
+	ip := net.ParseIP(s)
+	if ip == nil {
+		return nil, fmt.Errorf("invalid IP address %q", s)
+	}
+	if v4 := ip.To4(); v4 != nil {
+		return &net.IPNet{IP: v4, Mask: net.CIDRMask(32, 32)}, nil
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)}, nil
+}
+
+// Contains reports whether ip is inside one of the allowlisted networks.
+func (a *IPAllowlist) Contains(ip string) bool {
+	parsed := net.ParseIP(strings.TrimSpace(ip))
+	if parsed == nil {
+		return false
+	}
+	for _, n := range a.nets {
+		if n.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+// Allowlist returns a itself, so that a static list can be used as an
+// IPAllowlistSource.
+func (a *IPAllowlist) Allowlist() *IPAllowlist {
+	return a
+}
+
+// String returns the networks in CIDR notation, separated by commas.
+func (a *IPAllowlist) String() string {
+	parts := make([]string, len(a.nets))
+	for i, n := range a.nets {
+		parts[i] = n.String()
+	}
+	return strings.Join(parts, ",")
+}
+
+// IPAllowlistLoader keeps an allowlist that is periodically reloaded from a
+// file or URL. The current list is swapped atomically, so Allowlist may be
+// called from any goroutine while a refresh is running.
+type IPAllowlistLoader struct {
+	load    func(ctx context.Context) (io.ReadCloser, error)
+	current atomic.Value // *IPAllowlist
+
+	// OnError, if set, receives refresh errors from Run. The previous list
+	// stays in effect after a failed refresh.
+	OnError func(error)
+}
+
+// NewIPAllowlistLoader creates a loader that reads the list with load. Until
+// the first successful Refresh, the loader serves initial, or the default
+// allowlist if initial is nil.
+func NewIPAllowlistLoader(load func(ctx context.Context) (io.ReadCloser, error), initial *IPAllowlist) *IPAllowlistLoader {
+	if initial == nil {
+		initial = DefaultIPAllowlist()
+	}
+	l := &IPAllowlistLoader{load: load}
+	l.current.Store(initial)
+	return l
+}
+
+// NewFileIPAllowlistLoader creates a loader that reads the list from path.
+func NewFileIPAllowlistLoader(path string, initial *IPAllowlist) *IPAllowlistLoader {
+	return NewIPAllowlistLoader(func(ctx context.Context) (io.ReadCloser, error) {
+		return os.Open(path)
+	}, initial)
+}
+
+// NewURLIPAllowlistLoader creates a loader that fetches the list from rawURL.
+// If httpClient is nil, http.DefaultClient is used.
+func NewURLIPAllowlistLoader(rawURL string, httpClient *http.Client, initial *IPAllowlist) *IPAllowlistLoader {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return NewIPAllowlistLoader(func(ctx context.Context) (io.ReadCloser, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return nil, fmt.Errorf("fetch IP allowlist: unexpected status %s", resp.Status)
+		}
+		return resp.Body, nil
+	}, initial)
+}
+
+// Allowlist returns the most recently loaded list.
+func (l *IPAllowlistLoader) Allowlist() *IPAllowlist {
+	return l.current.Load().(*IPAllowlist)
+}
+
+// Refresh reloads the list and swaps it in. On error the current list is kept.
+func (l *IPAllowlistLoader) Refresh(ctx context.Context) error {
+	rc, err := l.load(ctx)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	a, err := ParseIPAllowlist(rc)
+	if err != nil {
+		return err
+	}
+	l.current.Store(a)
+	return nil
+}
+
+// Run refreshes the list every interval until ctx is done. It does not
+// refresh immediately; call Refresh first if the initial load matters.
+func (l *IPAllowlistLoader) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := l.Refresh(ctx); err != nil && l.OnError != nil {
+				l.OnError(err)
+			}
+		}
+	}
+}
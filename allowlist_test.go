@@ -0,0 +1,102 @@
+// allowlist_test.go
+package paymentwall
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDefaultIPAllowlist(t *testing.T) {
+	a := DefaultIPAllowlist()
+	for _, ip := range []string{"174.36.92.186", "174.37.14.28", "216.127.71.0", "216.127.71.255", "::ffff:174.36.96.66"} {
+		if !a.Contains(ip) {
+			t.Errorf("Contains(%s) = false; want true", ip)
+		}
+	}
+	for _, ip := range []string{"8.8.8.8", "216.127.72.1", "174.36.92.188", "", "bogus"} {
+		if a.Contains(ip) {
+			t.Errorf("Contains(%q) = true; want false", ip)
+		}
+	}
+}
+
+func TestNewIPAllowlist_IPv6AndErrors(t *testing.T) {
+	a, err := NewIPAllowlist("2001:db8::/32", "2a00::1", "10.0.0.0/8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !a.Contains("2001:db8:1::5") || !a.Contains("2a00::1") || !a.Contains("10.1.2.3") {
+		t.Errorf("allowlist %s misses expected addresses", a)
+	}
+	if a.Contains("2a00::2") {
+		t.Error("Contains(2a00::2) = true; want false")
+	}
+	if _, err := NewIPAllowlist("10.0.0.0/33"); err == nil {
+		t.Error("expected error on invalid CIDR")
+	}
+	if _, err := NewIPAllowlist("not-an-ip"); err == nil {
+		t.Error("expected error on invalid IP")
+	}
+}
+
+func TestParseIPAllowlist(t *testing.T) {
+	a, err := ParseIPAllowlist(strings.NewReader("# staging\n10.0.0.1\n\n  192.168.0.0/16 # office\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := a.String(); got != "10.0.0.1/32,192.168.0.0/16" {
+		t.Errorf("String = %q", got)
+	}
+	if _, err := ParseIPAllowlist(strings.NewReader("# nothing\n")); err == nil {
+		t.Error("expected error on empty allowlist")
+	}
+}
+
+func TestFileIPAllowlistLoader(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "allowlist.txt")
+	l := NewFileIPAllowlistLoader(path, nil)
+	if !l.Allowlist().Contains("174.36.92.186") {
+		t.Error("loader should serve the default list before the first refresh")
+	}
+	// missing file keeps the previous list
+	if err := l.Refresh(context.Background()); err == nil {
+		t.Error("Refresh of missing file: want error")
+	}
+	if err := os.WriteFile(path, []byte("10.0.0.0/8\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if !l.Allowlist().Contains("10.9.9.9") || l.Allowlist().Contains("174.36.92.186") {
+		t.Errorf("allowlist after refresh = %s", l.Allowlist())
+	}
+}
+
+func TestURLIPAllowlistLoader_ClientOverride(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("203.0.113.0/24\n"))
+	}))
+	defer srv.Close()
+
+	l := NewURLIPAllowlistLoader(srv.URL, srv.Client(), nil)
+	if err := l.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	cl := NewClient("k", "s", APIGoods)
+	cl.IPAllowlist = l
+	params := map[string]any{"uid": "u", "goodsid": "g", "type": "0", "ref": "r", "sig": "x"}
+
+	if err := NewPingback(cl, params, "203.0.113.7").ValidateErr(); !errors.Is(err, ErrSignatureMismatch) {
+		t.Errorf("ValidateErr from loaded range = %v; want ErrSignatureMismatch", err)
+	}
+	if err := NewPingback(cl, params, "174.36.92.186").ValidateErr(); !errors.Is(err, ErrIPNotWhitelisted) {
+		t.Errorf("ValidateErr from default range = %v; want ErrIPNotWhitelisted", err)
+	}
+}
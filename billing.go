@@ -0,0 +1,96 @@
+// Package paymentwall provides a Go SDK for interacting with the Paymentwall APIs.
+package paymentwall
+
+import (
+	"fmt"
+	"time"
+)
+
+// NextBillingDate returns the date one subscription period after from. Adding
+// months or years clamps to the end of the target month, so Jan 31 + 1 month
+// is Feb 28 (Feb 29 in leap years). Because clamping loses the original day,
+// chaining NextBillingDate drifts (Jan 31, Feb 28, Mar 28); use
+// BillingSchedule to compute a series of renewals.
+//
+// It returns an error if p is not a valid subscription.
+func (p *Product) NextBillingDate(from time.Time) (time.Time, error) {
+	if err := p.checkBillable(); err != nil {
+		return time.Time{}, err
+	}
+	return addPeriods(from, p.PeriodLength, p.PeriodType, 1), nil
+}
+
+// BillingSchedule returns the first n billing dates of a subscription bought at
+// start. If p has a TrialProduct, start is the trial charge and the first full
+// charge follows one trial period later. Renewals are computed from that
+// anchor rather than from each other, so a subscription started on Jan 31
+// renews on Feb 28, Mar 31, Apr 30 and so on. A non-recurring subscription is
+// billed once.
+//
+// It returns an error if p is not a valid subscription.
+func (p *Product) BillingSchedule(start time.Time, n int) ([]time.Time, error) {
+	if err := p.checkBillable(); err != nil {
+		return nil, err
+	}
+	if n <= 0 {
+		return nil, nil
+	}
+	if !p.Recurring {
+		return []time.Time{start}, nil
+	}
+
+	dates := make([]time.Time, 0, n)
+	anchor := start
+	if t := p.TrialProduct; t != nil {
+		dates = append(dates, start)
+		anchor = addPeriods(start, t.PeriodLength, t.PeriodType, 1)
+	}
+	for k := 0; len(dates) < n; k++ {
+		dates = append(dates, addPeriods(anchor, p.PeriodLength, p.PeriodType, k))
+	}
+	return dates, nil
+}
+
+// checkBillable reports an error unless p is a valid subscription.
+func (p *Product) checkBillable() error {
+	if err := p.Validate(); err != nil {
+		return err
+	}
+	if p.Type != ProductTypeSubscription {
+		return fmt.Errorf("%w %s: fixed product has no billing period", ErrInvalidProduct, p.ID)
+	}
+	return nil
+}
+
+// addPeriods returns t plus n periods of length units, keeping the time of day
+// and clamping the day of month for month and year units.
+func addPeriods(t time.Time, length int, unit string, n int) time.Time {
+	switch unit {
+	case PeriodDay:
+		return t.AddDate(0, 0, n*length)
+	case PeriodWeek:
+		return t.AddDate(0, 0, 7*n*length)
+	case PeriodMonth:
+		return addMonths(t, n*length)
+	case PeriodYear:
+		return addMonths(t, 12*n*length)
+	}
+	return t
+}
+
+// addMonths adds months to t, clamping the day to the last day of the target
+// month instead of overflowing into the next one as time.AddDate does.
+func addMonths(t time.Time, months int) time.Time {
+	y, m, d := t.Date()
+	first := time.Date(y, m+time.Month(months), 1, 0, 0, 0, 0, t.Location())
+	if last := daysIn(first.Year(), first.Month()); d > last {
+		d = last
+	}
+	hh, mm, ss := t.Clock()
+	return time.Date(first.Year(), first.Month(), d, hh, mm, ss, t.Nanosecond(), t.Location())
+}
+
+// daysIn returns the number of days in month m of year y.
+func daysIn(y int, m time.Month) int {
+	return time.Date(y, m+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
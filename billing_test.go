@@ -0,0 +1,103 @@
+// billing_test.go
+package paymentwall
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func date(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 10, 30, 0, 0, time.UTC)
+}
+
+func TestNextBillingDate(t *testing.T) {
+	cases := []struct {
+		length int
+		unit   string
+		from   time.Time
+		want   time.Time
+	}{
+		{1, PeriodDay, date(2024, 2, 28), date(2024, 2, 29)},
+		{2, PeriodWeek, date(2024, 12, 25), date(2025, 1, 8)},
+		{1, PeriodMonth, date(2023, 1, 31), date(2023, 2, 28)},
+		{1, PeriodMonth, date(2024, 1, 31), date(2024, 2, 29)},
+		{3, PeriodMonth, date(2024, 11, 30), date(2025, 2, 28)},
+		{1, PeriodMonth, date(2024, 3, 31), date(2024, 4, 30)},
+		{1, PeriodYear, date(2024, 2, 29), date(2025, 2, 28)},
+		{4, PeriodYear, date(2024, 2, 29), date(2028, 2, 29)},
+	}
+	for _, tc := range cases {
+		p := &Product{ID: "s", Type: ProductTypeSubscription, PeriodLength: tc.length, PeriodType: tc.unit}
+		got, err := p.NextBillingDate(tc.from)
+		if err != nil {
+			t.Fatalf("%d %s: %v", tc.length, tc.unit, err)
+		}
+		if !got.Equal(tc.want) {
+			t.Errorf("%d %s from %s = %s; want %s", tc.length, tc.unit, tc.from.Format("2006-01-02"), got, tc.want)
+		}
+	}
+}
+
+func TestNextBillingDate_KeepsLocation(t *testing.T) {
+	loc := time.FixedZone("UTC+3", 3*3600)
+	p := &Product{ID: "s", Type: ProductTypeSubscription, PeriodLength: 1, PeriodType: PeriodMonth}
+	from := time.Date(2024, 5, 31, 23, 15, 0, 0, loc)
+	got, _ := p.NextBillingDate(from)
+	if want := time.Date(2024, 6, 30, 23, 15, 0, 0, loc); !got.Equal(want) || got.Location() != loc {
+		t.Errorf("NextBillingDate = %s; want %s", got, want)
+	}
+}
+
+func TestNextBillingDate_Fixed(t *testing.T) {
+	p := &Product{ID: "f", Type: ProductTypeFixed}
+	if _, err := p.NextBillingDate(date(2024, 1, 1)); !errors.Is(err, ErrInvalidProduct) {
+		t.Errorf("NextBillingDate fixed = %v; want ErrInvalidProduct", err)
+	}
+}
+
+func TestBillingSchedule_AnchoredMonthEnd(t *testing.T) {
+	p := &Product{ID: "s", Type: ProductTypeSubscription, PeriodLength: 1, PeriodType: PeriodMonth, Recurring: true}
+	got, err := p.BillingSchedule(date(2023, 1, 31), 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []time.Time{date(2023, 1, 31), date(2023, 2, 28), date(2023, 3, 31), date(2023, 4, 30)}
+	assertDates(t, got, want)
+}
+
+func TestBillingSchedule_Trial(t *testing.T) {
+	trial := &Product{ID: "t", Type: ProductTypeSubscription, PeriodLength: 7, PeriodType: PeriodDay}
+	p := &Product{ID: "s", Type: ProductTypeSubscription, PeriodLength: 1, PeriodType: PeriodMonth, Recurring: true, TrialProduct: trial}
+	got, err := p.BillingSchedule(date(2024, 1, 24), 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []time.Time{date(2024, 1, 24), date(2024, 1, 31), date(2024, 2, 29), date(2024, 3, 31)}
+	assertDates(t, got, want)
+}
+
+func TestBillingSchedule_NonRecurring(t *testing.T) {
+	p := &Product{ID: "s", Type: ProductTypeSubscription, PeriodLength: 1, PeriodType: PeriodYear}
+	got, err := p.BillingSchedule(date(2024, 1, 1), 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertDates(t, got, []time.Time{date(2024, 1, 1)})
+
+	if got, _ := p.BillingSchedule(date(2024, 1, 1), 0); len(got) != 0 {
+		t.Errorf("BillingSchedule n=0 = %v; want empty", got)
+	}
+}
+
+func assertDates(t *testing.T, got, want []time.Time) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("got %d dates %v; want %d", len(got), got, len(want))
+	}
+	for i := range want {
+		if !got[i].Equal(want[i]) {
+			t.Errorf("date[%d] = %s; want %s", i, got[i], want[i])
+		}
+	}
+}
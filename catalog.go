@@ -0,0 +1,259 @@
+// Package paymentwall provides a Go SDK for interacting with the Paymentwall APIs.
+package paymentwall
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Catalog is an immutable set of products indexed by ID. It is safe for
+// concurrent use; the products it returns are shared and must not be modified.
+type Catalog struct {
+	products []*Product
+	byID     map[string]*Product
+}
+
+// NewCatalog builds a Catalog from products, in order. Every product must pass
+// Validate and IDs must be unique. Trial products are looked up by their own
+// ID only if they are listed too.
+func NewCatalog(products ...*Product) (*Catalog, error) {
+	c := &Catalog{byID: make(map[string]*Product, len(products))}
+	for _, p := range products {
+		if p == nil {
+			return nil, fmt.Errorf("catalog: nil product")
+		}
+		if p.ID == "" {
+			return nil, fmt.Errorf("%w: empty product ID", ErrInvalidProduct)
+		}
+		if _, dup := c.byID[p.ID]; dup {
+			return nil, fmt.Errorf("catalog: duplicate product ID %q", p.ID)
+		}
+		if err := p.Validate(); err != nil {
+			return nil, err
+		}
+		c.byID[p.ID] = p
+		c.products = append(c.products, p)
+	}
+	return c, nil
+}
+
+// Product returns the product with the given ID.
+func (c *Catalog) Product(id string) (*Product, bool) {
+	p, ok := c.byID[id]
+	return p, ok
+}
+
+// lookup returns the product with the given ID or an error matching
+// ErrUnknownProduct.
+func (c *Catalog) lookup(id string) (*Product, error) {
+	if p, ok := c.byID[id]; ok {
+		return p, nil
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnknownProduct, id)
+}
+
+// Products returns all products in the order they were loaded.
+func (c *Catalog) Products() []*Product {
+	return append([]*Product(nil), c.products...)
+}
+
+// Len returns the number of products.
+func (c *Catalog) Len() int {
+	return len(c.products)
+}
+
+// catalogEntry is one product in a catalog file. Trial names the ID of another
+// entry in the same file.
+type catalogEntry struct {
+	ID           string      `json:"id"`
+	Name         string      `json:"name"`
+	Amount       json.Number `json:"amount"`
+	Currency     string      `json:"currency"`
+	Type         string      `json:"type"`
+	PeriodLength int         `json:"period_length"`
+	PeriodType   string      `json:"period_type"`
+	Recurring    bool        `json:"recurring"`
+	Trial        string      `json:"trial"`
+}
+
+// product converts e to a Product without its trial. The amount is parsed
+// exactly and must fit the currency's minor unit.
+func (e catalogEntry) product() (*Product, error) {
+	currency, err := normalizeCurrencyCode(e.ID, e.Currency)
+	if err != nil {
+		return nil, err
+	}
+	price, err := ParseMoney(string(e.Amount), currency)
+	if err != nil {
+		return nil, fmt.Errorf("product %s: %w", e.ID, err)
+	}
+	prodType := e.Type
+	if prodType == "" {
+		prodType = ProductTypeFixed
+	}
+	return &Product{
+		ID:           e.ID,
+		Amount:       price.Float64(),
+		Price:        price,
+		CurrencyCode: currency,
+		Name:         e.Name,
+		Type:         prodType,
+		PeriodLength: e.PeriodLength,
+		PeriodType:   e.PeriodType,
+		Recurring:    e.Recurring,
+	}, nil
+}
+
+// catalogFromEntries converts entries, links trials by ID and validates the
+// result through NewCatalog.
+func catalogFromEntries(entries []catalogEntry) (*Catalog, error) {
+	products := make([]*Product, len(entries))
+	byID := make(map[string]*Product, len(entries))
+	for i, e := range entries {
+		p, err := e.product()
+		if err != nil {
+			return nil, fmt.Errorf("catalog: %w", err)
+		}
+		products[i] = p
+		byID[p.ID] = p
+	}
+	for i, e := range entries {
+		if e.Trial == "" {
+			continue
+		}
+		trial, ok := byID[e.Trial]
+		if !ok {
+			return nil, fmt.Errorf("catalog: product %s: unknown trial product %q", e.ID, e.Trial)
+		}
+		products[i].TrialProduct = trial
+	}
+	return NewCatalog(products...)
+}
+
+// LoadCatalogJSON reads a catalog from a JSON array of products:
+//
+//	[
+//	  {"id": "trial7", "amount": "0.99", "currency": "USD", "type": "subscription",
+//	   "period_length": 7, "period_type": "day"},
+//	  {"id": "pro", "name": "Pro plan", "amount": 9.99, "currency": "USD",
+//	   "type": "subscription", "period_length": 1, "period_type": "month",
+//	   "recurring": true, "trial": "trial7"}
+//	]
+//
+// amount may be a number or a string. type defaults to fixed. trial is the ID
+// of another product in the file. Unknown fields are rejected.
+func LoadCatalogJSON(r io.Reader) (*Catalog, error) {
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	var entries []catalogEntry
+	if err := dec.Decode(&entries); err != nil {
+		return nil, fmt.Errorf("catalog: %w", err)
+	}
+	return catalogFromEntries(entries)
+}
+
+// catalogColumns are the CSV columns LoadCatalogCSV understands.
+var catalogColumns = map[string]bool{
+	"id": true, "name": true, "amount": true, "currency": true, "type": true,
+	"period_length": true, "period_type": true, "recurring": true, "trial": true,
+}
+
+// LoadCatalogCSV reads a catalog from CSV with a header row naming the
+// columns, in any order:
+//
+//	id,name,amount,currency,type,period_length,period_type,recurring,trial
+//	trial7,,0.99,USD,subscription,7,day,,
+//	pro,Pro plan,9.99,USD,subscription,1,month,true,trial7
+//
+// id, amount and currency are required; the other columns are optional and
+// mean the same as in LoadCatalogJSON. Empty period_length and recurring
+// cells mean 0 and false.
+func LoadCatalogCSV(r io.Reader) (*Catalog, error) {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("catalog: reading header: %w", err)
+	}
+	col := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !catalogColumns[name] {
+			return nil, fmt.Errorf("catalog: unknown column %q", name)
+		}
+		col[name] = i
+	}
+	for _, name := range []string{"id", "amount", "currency"} {
+		if _, ok := col[name]; !ok {
+			return nil, fmt.Errorf("catalog: missing column %q", name)
+		}
+	}
+
+	var entries []catalogEntry
+	for {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("catalog: %w", err)
+		}
+		line, _ := cr.FieldPos(0)
+		cell := func(name string) string {
+			if i, ok := col[name]; ok {
+				return strings.TrimSpace(rec[i])
+			}
+			return ""
+		}
+		e := catalogEntry{
+			ID:         cell("id"),
+			Name:       cell("name"),
+			Amount:     json.Number(cell("amount")),
+			Currency:   cell("currency"),
+			Type:       cell("type"),
+			PeriodType: cell("period_type"),
+			Trial:      cell("trial"),
+		}
+		if s := cell("period_length"); s != "" {
+			if e.PeriodLength, err = strconv.Atoi(s); err != nil {
+				return nil, fmt.Errorf("catalog line %d: invalid period_length %q", line, s)
+			}
+		}
+		if s := cell("recurring"); s != "" {
+			if e.Recurring, err = strconv.ParseBool(s); err != nil {
+				return nil, fmt.Errorf("catalog line %d: invalid recurring %q", line, s)
+			}
+		}
+		entries = append(entries, e)
+	}
+	return catalogFromEntries(entries)
+}
+
+// LoadCatalogFile reads a catalog from a .json or .csv file.
+func LoadCatalogFile(path string) (*Catalog, error) {
+	var load func(io.Reader) (*Catalog, error)
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		load = LoadCatalogJSON
+	case ".csv":
+		load = LoadCatalogCSV
+	default:
+		return nil, fmt.Errorf("catalog %s: unsupported file type", path)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	c, err := load(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return c, nil
+}
@@ -0,0 +1,151 @@
+// catalog_test.go
+package paymentwall
+
+import (
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const catalogJSON = `[
+  {"id": "trial7", "amount": "0.99", "currency": "usd", "type": "subscription",
+   "period_length": 7, "period_type": "day"},
+  {"id": "pro", "name": "Pro plan", "amount": 9.99, "currency": "USD",
+   "type": "subscription", "period_length": 1, "period_type": "month",
+   "recurring": true, "trial": "trial7"},
+  {"id": "gems", "name": "Gems", "amount": "500", "currency": "JPY"}
+]`
+
+const catalogCSV = `id,name,amount,currency,type,period_length,period_type,recurring,trial
+trial7,,0.99,usd,subscription,7,day,,
+pro,Pro plan,9.99,USD,subscription,1,month,true,trial7
+gems,Gems,500,JPY,,,,,
+`
+
+func checkSampleCatalog(t *testing.T, c *Catalog) {
+	t.Helper()
+	if c.Len() != 3 {
+		t.Fatalf("Len = %d; want 3", c.Len())
+	}
+	pro, ok := c.Product("pro")
+	if !ok {
+		t.Fatal("Product(pro) not found")
+	}
+	if pro.Name != "Pro plan" || pro.GetPrice() != NewMoney(999, "USD") || !pro.Recurring ||
+		pro.PeriodLength != 1 || pro.PeriodType != PeriodMonth {
+		t.Errorf("pro = %+v", pro)
+	}
+	trial, _ := c.Product("trial7")
+	if pro.TrialProduct != trial || trial.CurrencyCode != "USD" {
+		t.Errorf("pro.TrialProduct = %+v; want %+v", pro.TrialProduct, trial)
+	}
+	gems, _ := c.Product("gems")
+	if gems.Type != ProductTypeFixed || gems.GetPrice() != NewMoney(500, "JPY") {
+		t.Errorf("gems = %+v", gems)
+	}
+	if ids := []string{c.Products()[0].ID, c.Products()[1].ID, c.Products()[2].ID}; strings.Join(ids, ",") != "trial7,pro,gems" {
+		t.Errorf("Products order = %v", ids)
+	}
+}
+
+func TestLoadCatalogJSON(t *testing.T) {
+	c, err := LoadCatalogJSON(strings.NewReader(catalogJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkSampleCatalog(t, c)
+}
+
+func TestLoadCatalogCSV(t *testing.T) {
+	c, err := LoadCatalogCSV(strings.NewReader(catalogCSV))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkSampleCatalog(t, c)
+}
+
+func TestLoadCatalogFile(t *testing.T) {
+	dir := t.TempDir()
+	for name, data := range map[string]string{"p.json": catalogJSON, "p.csv": catalogCSV} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		c, err := LoadCatalogFile(path)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		checkSampleCatalog(t, c)
+	}
+	if _, err := LoadCatalogFile(filepath.Join(dir, "p.yaml")); err == nil {
+		t.Error("LoadCatalogFile(.yaml) = nil error")
+	}
+}
+
+func TestLoadCatalog_Errors(t *testing.T) {
+	cases := map[string]string{
+		"unknown field":     `[{"id": "a", "amount": 1, "currency": "USD", "price": 1}]`,
+		"sub cent":          `[{"id": "a", "amount": "1.005", "currency": "USD"}]`,
+		"unknown currency":  `[{"id": "a", "amount": 1, "currency": "XXY"}]`,
+		"duplicate":         `[{"id": "a", "amount": 1, "currency": "USD"}, {"id": "a", "amount": 2, "currency": "USD"}]`,
+		"unknown trial":     `[{"id": "a", "amount": 1, "currency": "USD", "type": "subscription", "period_length": 1, "period_type": "month", "recurring": true, "trial": "b"}]`,
+		"zero period":       `[{"id": "a", "amount": 1, "currency": "USD", "type": "subscription", "period_type": "month"}]`,
+		"trial not allowed": `[{"id": "t", "amount": 1, "currency": "USD", "type": "subscription", "period_length": 1, "period_type": "day"}, {"id": "a", "amount": 1, "currency": "USD", "type": "subscription", "period_length": 1, "period_type": "month", "trial": "t"}]`,
+	}
+	for name, data := range cases {
+		if _, err := LoadCatalogJSON(strings.NewReader(data)); err == nil {
+			t.Errorf("%s: LoadCatalogJSON = nil error", name)
+		}
+	}
+	if _, err := LoadCatalogJSON(strings.NewReader(cases["unknown currency"])); !errors.Is(err, ErrInvalidProduct) {
+		t.Errorf("LoadCatalogJSON unknown currency = %v; want ErrInvalidProduct", err)
+	}
+	if _, err := LoadCatalogCSV(strings.NewReader("id,amount,currency,colour\na,1,USD,red\n")); err == nil {
+		t.Error("LoadCatalogCSV unknown column = nil error")
+	}
+	if _, err := LoadCatalogCSV(strings.NewReader("id,amount\na,1\n")); err == nil {
+		t.Error("LoadCatalogCSV missing currency column = nil error")
+	}
+	if _, err := LoadCatalogCSV(strings.NewReader("id,amount,currency,recurring\na,1,USD,maybe\n")); err == nil {
+		t.Error("LoadCatalogCSV invalid recurring = nil error")
+	}
+}
+
+func TestPingback_GetProductFromCatalog(t *testing.T) {
+	c, err := LoadCatalogJSON(strings.NewReader(catalogJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cl := NewClient("k", "s", APIGoods)
+	cl.Catalog = c
+	pb := NewPingbackFromValues(cl, url.Values{"goodsid": {"pro"}, "slength": {"1"}, "speriod": {"month"}}, "")
+	p, err := pb.GetProduct()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, _ := c.Product("pro"); p != want {
+		t.Errorf("GetProduct = %+v; want catalog entry", p)
+	}
+
+	pb = NewPingbackFromValues(cl, url.Values{"goodsid": {"nope"}}, "")
+	if _, err := pb.GetProduct(); !errors.Is(err, ErrUnknownProduct) {
+		t.Errorf("GetProduct unknown = %v; want ErrUnknownProduct", err)
+	}
+
+	cl.APIType = APICart
+	pb = NewPingbackFromValues(cl, url.Values{"goodsid[0]": {"gems"}, "goodsid[1]": {"pro"}}, "")
+	prods, err := pb.GetProducts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(prods) != 2 || prods[0].Name != "Gems" || prods[1].Name != "Pro plan" {
+		t.Errorf("GetProducts = %+v", prods)
+	}
+	pb = NewPingbackFromValues(cl, url.Values{"goodsid[0]": {"gems"}, "goodsid[1]": {"nope"}}, "")
+	if _, err := pb.GetProducts(); !errors.Is(err, ErrUnknownProduct) {
+		t.Errorf("GetProducts unknown = %v; want ErrUnknownProduct", err)
+	}
+}
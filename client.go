@@ -4,10 +4,12 @@ package paymentwall
 import (
 	"crypto/md5"
 	"crypto/sha256"
+	"crypto/subtle"
 	"encoding/hex"
 	"fmt"
 	"sort"
 	"strings"
+	"sync"
 )
 
 // APIType identifies the Paymentwall API mode.
@@ -36,12 +38,37 @@ const (
 	VersionString   = "0.1.1"
 )
 
-// Client holds global configuration and error state for the SDK.
+// Client holds global configuration for the SDK.
+//
+// A Client is safe for concurrent use by multiple goroutines. Its exported
+// fields must not be modified once it is in use; secret keys can be rotated at
+// any time with SetSecretKeys, RotateSecretKey, AddSecretKey and
+// RemoveSecretKey. Errors are returned per call rather than stored on the
+// client; the SDK never writes to the deprecated Errors field.
 type Client struct {
 	APIType   APIType
 	AppKey    string
-	SecretKey string
-	Errors    []string
+	SecretKey string // initial secret key; superseded once the key set is changed at runtime
+	// IPAllowlist overrides the pingback source IP allowlist; nil means
+	// DefaultIPAllowlist.
+	IPAllowlist IPAllowlistSource
+	// TrustedProxies, if set, lets NewPingbackFromRequest take the source IP
+	// from forwarding headers added by these proxies.
+	TrustedProxies *TrustedProxies
+	// Catalog, if set, is used by Pingback.GetProduct and GetProducts to
+	// return full product definitions instead of stubs.
+	Catalog *Catalog
+
+	// Errors holds messages recorded with AppendError.
+	//
+	// Deprecated: the SDK returns errors from each call instead of recording
+	// them here. Use AppendError and ErrorSummary, which are safe for
+	// concurrent use, rather than accessing Errors directly.
+	Errors []string
+	errMu  sync.Mutex
+
+	mu      sync.RWMutex
+	secrets []string // primary first; nil until SetSecretKeys, RotateSecretKey or AddSecretKey is called
 }
 
 // NewClient initializes a Paymentwall Client with the given keys and API type.
@@ -55,17 +82,129 @@ func NewClient(appKey, secretKey string, api APIType) *Client {
 }
 
 // SetAPIType allows overriding the API type on an existing client.
+//
+// Deprecated: SetAPIType is not safe to call while the client is in use by
+// other goroutines. Create a separate Client for each API type instead.
 func (c *Client) SetAPIType(api APIType) {
 	c.APIType = api
 }
 
+// ipAllowlist returns the allowlist used to check pingback source IPs.
+func (c *Client) ipAllowlist() *IPAllowlist {
+	if c.IPAllowlist != nil {
+		if a := c.IPAllowlist.Allowlist(); a != nil {
+			return a
+		}
+	}
+	return DefaultIPAllowlist()
+}
+
+// SecretKeys returns the secret keys accepted by the client. The first key is
+// the primary one, used to sign widgets; all keys are accepted when verifying.
+func (c *Client) SecretKeys() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.secrets == nil {
+		if c.SecretKey == "" {
+			return nil
+		}
+		return []string{c.SecretKey}
+	}
+	keys := make([]string, len(c.secrets))
+	copy(keys, c.secrets)
+	return keys
+}
+
+// SetSecretKeys replaces the accepted secret keys. The first key becomes the
+// primary one. Empty and duplicate keys are dropped.
+func (c *Client) SetSecretKeys(keys ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.secrets = appendSecrets(make([]string, 0, len(keys)), keys...)
+}
+
+// RotateSecretKey makes key the primary secret key while still accepting the
+// previous keys for verification until they are removed.
+func (c *Client) RotateSecretKey(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.secrets = appendSecrets([]string{}, append([]string{key}, c.secretsLocked()...)...)
+}
+
+// AddSecretKey accepts key for verification without changing the primary key.
+func (c *Client) AddSecretKey(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.secrets = appendSecrets(append([]string{}, c.secretsLocked()...), key)
+}
+
+// RemoveSecretKey stops accepting key. Removing the primary key promotes the
+// next one.
+func (c *Client) RemoveSecretKey(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	keys := make([]string, 0, len(c.secretsLocked()))
+	for _, k := range c.secretsLocked() {
+		if k != key {
+			keys = append(keys, k)
+		}
+	}
+	c.secrets = keys
+}
+
+// secretsLocked returns the current key set; c.mu must be held.
+func (c *Client) secretsLocked() []string {
+	if c.secrets == nil && c.SecretKey != "" {
+		return []string{c.SecretKey}
+	}
+	return c.secrets
+}
+
+// appendSecrets appends the non-empty keys not already present in dst.
+func appendSecrets(dst []string, keys ...string) []string {
+	for _, k := range keys {
+		if k == "" {
+			continue
+		}
+		dup := false
+		for _, d := range dst {
+			if d == k {
+				dup = true
+				break
+			}
+		}
+		if !dup {
+			dst = append(dst, k)
+		}
+	}
+	return dst
+}
+
+// primarySecret returns the key used for signing, or "" if none is set.
+func (c *Client) primarySecret() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if keys := c.secretsLocked(); len(keys) > 0 {
+		return keys[0]
+	}
+	return ""
+}
+
 // AppendError records an error message in the client's error list.
+//
+// Deprecated: handle the error returned by each call instead.
 func (c *Client) AppendError(err string) {
+	c.errMu.Lock()
+	defer c.errMu.Unlock()
 	c.Errors = append(c.Errors, err)
 }
 
 // ErrorSummary returns all recorded errors as a single string.
+//
+// Deprecated: handle the error returned by each call instead.
 func (c *Client) ErrorSummary() string {
+	c.errMu.Lock()
+	defer c.errMu.Unlock()
 	return strings.Join(c.Errors, "\n")
 }
 
@@ -81,71 +220,147 @@ func hashSHA256(s string) string {
 	return hex.EncodeToString(h[:])
 }
 
+// Param is a single key/value pair passed to the signer.
+type Param struct {
+	Key   string
+	Value any
+}
+
+// OrderedParams is a list of parameters whose order is preserved when signing
+// with SigV1. SigV2 and SigV3 sort the keys regardless of the input order.
+type OrderedParams []Param
+
+// NewOrderedParams builds OrderedParams from a map, with keys sorted.
+func NewOrderedParams(params map[string]any) OrderedParams {
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	op := make(OrderedParams, 0, len(keys))
+	for _, k := range keys {
+		op = append(op, Param{Key: k, Value: params[k]})
+	}
+	return op
+}
+
+// Get returns the value of the first parameter named key.
+func (op OrderedParams) Get(key string) (any, bool) {
+	for _, p := range op {
+		if p.Key == key {
+			return p.Value, true
+		}
+	}
+	return nil, false
+}
+
+// baseString concatenates key=value pairs, expanding slices and maps with
+// bracket notation (see encodeParams). Top-level order is kept unless sorted.
+func (op OrderedParams) baseString(sorted bool) string {
+	var base strings.Builder
+	for _, p := range encodeParams(op, sorted) {
+		base.WriteString(p.key)
+		base.WriteByte('=')
+		base.WriteString(p.value)
+	}
+	return base.String()
+}
+
 // CalculateSignature builds a signature string based on the provided parameters and version.
 // It implements SigV1, SigV2, and SigV3 signature algorithms as per Paymentwall's documentation.
+// A map has no order, so SigV1 signs its keys sorted; use CalculateOrderedSignature
+// when the SigV1 field order matters. Values may be slices, maps or structs
+// (see appendStruct), nested to any depth; they are signed in bracket notation, e.g. customer[firstname]=Ada
+// or ids[0]=7, in the same order Widget.GetURL lists them.
+// Scalars are formatted the same way as in the URL: booleans as 1 or 0, floats
+// without an exponent, and nil values are left out.
 func (c *Client) CalculateSignature(
 	params map[string]any,
 	version SignatureVersion,
 ) (string, error) {
-	if c.SecretKey == "" {
-		return "", fmt.Errorf("secret key cannot be empty")
-	}
+	return c.CalculateOrderedSignature(NewOrderedParams(params), version)
+}
 
-	var base strings.Builder
-	keys := make([]string, 0, len(params))
-	for k := range params {
-		keys = append(keys, k)
+// CalculateOrderedSignature is like CalculateSignature but keeps the given
+// parameter order for SigV1. It signs with the primary secret key.
+func (c *Client) CalculateOrderedSignature(
+	params OrderedParams,
+	version SignatureVersion,
+) (string, error) {
+	return calculateSignature(params, version, c.primarySecret())
+}
+
+// calculateSignature signs params with the given secret.
+func calculateSignature(
+	params OrderedParams,
+	version SignatureVersion,
+	secret string,
+) (string, error) {
+	if secret == "" {
+		return "", ErrEmptySecret
 	}
 
 	switch version {
 	case SigV1:
 		// v1: MD5 of parameters in provided order
-		// Maintain order as provided by pingback.go
-		for _, k := range keys {
-			v := params[k]
-			switch val := v.(type) {
-			case []any:
-				for i, item := range val {
-					base.WriteString(fmt.Sprintf("%s[%d]=%v", k, i, item))
-				}
-			default:
-				// Handle nil or missing values as empty string
-				if val == nil {
-					base.WriteString(fmt.Sprintf("%s=", k))
-				} else {
-					base.WriteString(fmt.Sprintf("%s=%v", k, val))
-				}
-			}
-		}
-		base.WriteString(c.SecretKey)
-		return hashMD5(base.String()), nil
+		return hashMD5(params.baseString(false) + secret), nil
 
 	case SigV2, SigV3:
 		// v2/v3: sorted key=value pairs + secret
-		sort.Strings(keys)
-		for _, k := range keys {
-			v := params[k]
-			switch val := v.(type) {
-			case []any:
-				for i, item := range val {
-					base.WriteString(fmt.Sprintf("%s[%d]=%v", k, i, item))
-				}
-			default:
-				// Handle nil or missing values as empty string
-				if val == nil {
-					base.WriteString(fmt.Sprintf("%s=", k))
-				} else {
-					base.WriteString(fmt.Sprintf("%s=%v", k, val))
-				}
-			}
-		}
-		base.WriteString(c.SecretKey)
+		base := params.baseString(true) + secret
 		if version == SigV2 {
-			return hashMD5(base.String()), nil
+			return hashMD5(base), nil
 		}
-		return hashSHA256(base.String()), nil
+		return hashSHA256(base), nil
 
 	default:
-		return "", fmt.Errorf("unsupported signature version: %d", version)
+		return "", fmt.Errorf("%w: %d", ErrUnsupportedSignatureVersion, version)
+	}
+}
+
+// VerifySignature checks sig against the signature of params calculated with
+// version, trying every key in SecretKeys. The "sig" and "sign" keys of params
+// are ignored. Hex case and surrounding whitespace in sig do not matter, and
+// the comparison runs in constant time. A mismatch is reported as
+// *SignatureMismatchError.
+func (c *Client) VerifySignature(params map[string]any, sig string, version SignatureVersion) error {
+	return c.VerifyOrderedSignature(NewOrderedParams(params), sig, version)
+}
+
+// VerifyOrderedSignature is like VerifySignature but keeps the given parameter
+// order for SigV1.
+func (c *Client) VerifyOrderedSignature(params OrderedParams, sig string, version SignatureVersion) error {
+	_, err := c.verifySignature(params, sig, version)
+	return err
+}
+
+// verifySignature returns the index in SecretKeys of the key that produced sig.
+func (c *Client) verifySignature(params OrderedParams, sig string, version SignatureVersion) (int, error) {
+	signed := make(OrderedParams, 0, len(params))
+	for _, p := range params {
+		if p.Key == "sig" || p.Key == "sign" {
+			continue
+		}
+		signed = append(signed, p)
+	}
+	keys := c.SecretKeys()
+	if len(keys) == 0 {
+		return -1, ErrEmptySecret
+	}
+	for i, key := range keys {
+		want, err := calculateSignature(signed, version, key)
+		if err != nil {
+			return -1, err
+		}
+		if signaturesEqual(want, sig) {
+			return i, nil
+		}
 	}
-}
\ No newline at end of file
+	return -1, &SignatureMismatchError{Version: version}
+}
+
+// signaturesEqual compares two hex signatures case-insensitively in constant time.
+func signaturesEqual(want, got string) bool {
+	got = strings.ToLower(strings.TrimSpace(got))
+	return subtle.ConstantTimeCompare([]byte(want), []byte(got)) == 1
+}
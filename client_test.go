@@ -2,10 +2,14 @@
 package paymentwall
 
 import (
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
 	"testing"
 )
 
-func TestNewClient_SetAPIType_AppendError_ErrorSummary(t *testing.T) {
+func TestNewClient_SetAPIType(t *testing.T) {
 	c := NewClient("app", "sec", APIVC)
 	if c.AppKey != "app" || c.SecretKey != "sec" || c.APIType != APIVC {
 		t.Fatalf("NewClient fields incorrect: %+v", c)
@@ -14,10 +18,29 @@ func TestNewClient_SetAPIType_AppendError_ErrorSummary(t *testing.T) {
 	if c.APIType != APIGoods {
 		t.Errorf("SetAPIType = %v; want %v", c.APIType, APIGoods)
 	}
-	c.AppendError("e1")
-	c.AppendError("e2")
-	if got := c.ErrorSummary(); got != "e1\ne2" {
-		t.Errorf("ErrorSummary = %q; want %q", got, "e1\ne2")
+}
+
+func TestClient_AppendError(t *testing.T) {
+	c := NewClient("app", "sec", APIVC)
+	c.AppendError("first")
+	c.AppendError("second")
+	if got := c.ErrorSummary(); got != "first\nsecond" {
+		t.Errorf("ErrorSummary = %q; want %q", got, "first\nsecond")
+	}
+
+	// safe for concurrent use, like the rest of Client
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.AppendError("x")
+			_ = c.ErrorSummary()
+		}()
+	}
+	wg.Wait()
+	if len(c.Errors) != 10 {
+		t.Errorf("len(Errors) = %d; want 10", len(c.Errors))
 	}
 }
 
@@ -87,3 +110,139 @@ func TestCalculateSignature_Slice(t *testing.T) {
 		t.Errorf("SigV2 slice = %s; want %s", got, want)
 	}
 }
+
+func TestCalculateOrderedSignature_SigV1KeepsOrder(t *testing.T) {
+	c := NewClient("app", "secret", APIGoods)
+	params := OrderedParams{
+		{Key: "uid", Value: "user1"},
+		{Key: "goodsid", Value: "gold_plan"},
+		{Key: "slength", Value: 1},
+		{Key: "speriod", Value: "month"},
+		{Key: "type", Value: 0},
+		{Key: "ref", Value: "t2"},
+	}
+	got, err := c.CalculateOrderedSignature(params, SigV1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// md5("uid=user1goodsid=gold_planslength=1speriod=monthtype=0ref=t2secret")
+	if want := "bd96db14360313181d773a7ff579c5c8"; got != want {
+		t.Errorf("SigV1 = %s; want %s", got, want)
+	}
+	// v2 sorts keys regardless of input order
+	v2, _ := c.CalculateOrderedSignature(params, SigV2)
+	m := map[string]any{}
+	for _, p := range params {
+		m[p.Key] = p.Value
+	}
+	if want, _ := c.CalculateSignature(m, SigV2); v2 != want {
+		t.Errorf("ordered SigV2 = %s; want %s", v2, want)
+	}
+}
+
+func TestVerifySignature(t *testing.T) {
+	c := NewClient("app", "sec", APIGoods)
+	params := map[string]any{"uid": "u", "widget": "pw", "sign_version": 3}
+	sig, err := c.CalculateSignature(params, SigV3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.VerifySignature(params, sig, SigV3); err != nil {
+		t.Errorf("VerifySignature = %v; want nil", err)
+	}
+	// hex case and the "sign" key itself are ignored
+	params["sign"] = sig
+	if err := c.VerifySignature(params, strings.ToUpper(sig), SigV3); err != nil {
+		t.Errorf("VerifySignature upper-case = %v; want nil", err)
+	}
+
+	err = c.VerifySignature(params, sig, SigV2)
+	var mismatch *SignatureMismatchError
+	if !errors.As(err, &mismatch) || mismatch.Version != SigV2 {
+		t.Fatalf("VerifySignature wrong version = %v; want *SignatureMismatchError{SigV2}", err)
+	}
+	if !strings.Contains(err.Error(), "sign_version 2") {
+		t.Errorf("error %q does not mention the version tried", err)
+	}
+}
+
+func TestClient_SecretKeyRotation(t *testing.T) {
+	c := NewClient("app", "old", APIGoods)
+	params := map[string]any{"uid": "u"}
+	oldSig, _ := c.CalculateSignature(params, SigV2)
+
+	c.RotateSecretKey("new")
+	if got := c.SecretKeys(); !reflect.DeepEqual(got, []string{"new", "old"}) {
+		t.Fatalf("SecretKeys = %v; want [new old]", got)
+	}
+	newSig, _ := c.CalculateSignature(params, SigV2)
+	if newSig == oldSig {
+		t.Error("signature still uses the old primary key")
+	}
+	for _, sig := range []string{oldSig, newSig} {
+		if err := c.VerifySignature(params, sig, SigV2); err != nil {
+			t.Errorf("VerifySignature(%s) = %v; want nil", sig, err)
+		}
+	}
+
+	c.RemoveSecretKey("old")
+	if err := c.VerifySignature(params, oldSig, SigV2); err == nil {
+		t.Error("old key still accepted after removal")
+	}
+	c.AddSecretKey("extra")
+	c.AddSecretKey("new")
+	if got := c.SecretKeys(); !reflect.DeepEqual(got, []string{"new", "extra"}) {
+		t.Errorf("SecretKeys = %v; want [new extra]", got)
+	}
+	c.SetSecretKeys()
+	if _, err := c.CalculateSignature(params, SigV2); err == nil {
+		t.Error("expected error with no secret keys")
+	}
+}
+
+// TestClient_ConcurrentUse is meant to be run with -race.
+func TestClient_ConcurrentUse(t *testing.T) {
+	c := NewClient("app", "sec", APIGoods)
+	prod, _ := NewProduct("p", 5.0, "USD", "N", ProductTypeFixed, 0, "", false, nil)
+	shared := NewWidget(c, "u", "pw", []*Product{prod}, nil)
+	tooMany := NewWidget(c, "u", "pw", []*Product{prod, prod}, nil)
+
+	sig, _ := c.CalculateSignature(map[string]any{
+		"uid": "u", "goodsid": "g", "type": "0", "ref": "r", "sign_version": int(SigV2),
+	}, SigV2)
+	params := map[string]any{
+		"uid": "u", "goodsid": "g", "type": "0", "ref": "r", "sign_version": "2", "sig": sig,
+	}
+
+	var wg sync.WaitGroup
+	for g := 0; g < 32; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				if _, err := shared.GetURL(); err != nil {
+					t.Errorf("GetURL: %v", err)
+					return
+				}
+				if _, err := tooMany.GetURL(); err == nil {
+					t.Error("GetURL with two Goods products: want error")
+					return
+				}
+				pb := NewPingback(c, params, "174.36.92.186")
+				if err := pb.ValidateErr(); err != nil {
+					t.Errorf("ValidateErr: %v", err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			c.AddSecretKey("next")
+			c.RemoveSecretKey("next")
+		}
+	}()
+	wg.Wait()
+}
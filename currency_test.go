@@ -0,0 +1,39 @@
+// currency_test.go
+package paymentwall
+
+import (
+	"sort"
+	"testing"
+)
+
+func TestLookupCurrency(t *testing.T) {
+	c, ok := LookupCurrency(" kwd ")
+	if !ok || c.Code != "KWD" || c.Numeric != "414" || c.MinorUnits != 3 || c.Name != "Kuwaiti Dinar" {
+		t.Errorf("LookupCurrency(kwd) = %+v, %v", c, ok)
+	}
+	if _, ok := LookupCurrency("USDD"); ok {
+		t.Error("LookupCurrency(USDD) found a currency")
+	}
+	all := Currencies()
+	if !sort.SliceIsSorted(all, func(i, j int) bool { return all[i].Code < all[j].Code }) {
+		t.Error("Currencies is not sorted by code")
+	}
+	for _, c := range all {
+		if len(c.Code) != 3 || len(c.Numeric) != 3 || c.Name == "" {
+			t.Errorf("malformed currency %+v", c)
+		}
+	}
+}
+
+func TestNewProduct_CurrencyValidation(t *testing.T) {
+	p, err := NewProduct("p", 1, "usd", "N", ProductTypeFixed, 0, "", false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.CurrencyCode != "USD" || p.Price.Currency != "USD" {
+		t.Errorf("CurrencyCode = %q; want USD", p.CurrencyCode)
+	}
+	if _, err := NewProduct("p", 1, "USDD", "N", ProductTypeFixed, 0, "", false, nil); err == nil {
+		t.Error("expected error on unknown currency code")
+	}
+}
@@ -0,0 +1,114 @@
+// Package paymentwall provides a Go SDK for interacting with the Paymentwall APIs.
+package paymentwall
+
+import (
+	"strconv"
+	"time"
+)
+
+// CustomerProfile describes the end-user for Paymentwall's risk scoring. It
+// is sent as customer[...] widget parameters; empty fields are omitted.
+type CustomerProfile struct {
+	FirstName string    // customer[firstname]
+	LastName  string    // customer[lastname]
+	Birthday  time.Time // customer[birthday], sent as Unix seconds
+	Sex       string    // customer[sex]: "male" or "female"
+	Username  string    // customer[username]
+	Address   string    // customer[address]
+	City      string    // customer[city]
+	State     string    // customer[state]
+	Zip       string    // customer[zip]
+	Country   string    // customer[country]: ISO 3166-1 alpha-2 code
+}
+
+// UserHistory describes the end-user's account history for Paymentwall's risk
+// scoring. It is sent as history[...] widget parameters; empty fields are
+// omitted.
+type UserHistory struct {
+	RegistrationDate          time.Time // history[registration_date], sent as Unix seconds
+	RegistrationCountry       string    // history[registration_country]: ISO 3166-1 alpha-2 code
+	RegistrationIP            string    // history[registration_ip]
+	RegistrationEmail         string    // history[registration_email]
+	RegistrationEmailVerified *bool     // history[registration_email_verified]: 1 or 0
+	RegistrationName          string    // history[registration_name]
+	RegistrationLastName      string    // history[registration_lastname]
+	RegistrationSource        string    // history[registration_source]
+	Membership                string    // history[membership]
+	MembershipDate            time.Time // history[membership_date], sent as Unix seconds
+	LoginsNumber              int       // history[logins_number]
+	PaymentsNumber            int       // history[payments_number]
+	PaymentsAmount            Decimal   // history[payments_amount]
+}
+
+// groupParams collects name[field] parameters with canonical string values,
+// so that the same profile always produces the same signature.
+type groupParams struct {
+	name   string
+	params map[string]any
+}
+
+func (g groupParams) setString(field, v string) {
+	if v != "" {
+		g.params[g.name+"["+field+"]"] = v
+	}
+}
+
+func (g groupParams) setTime(field string, t time.Time) {
+	if !t.IsZero() {
+		g.setString(field, strconv.FormatInt(t.Unix(), 10))
+	}
+}
+
+func (g groupParams) setInt(field string, n int) {
+	if n != 0 {
+		g.setString(field, strconv.Itoa(n))
+	}
+}
+
+func (g groupParams) setBool(field string, b *bool) {
+	if b == nil {
+		return
+	}
+	if *b {
+		g.setString(field, "1")
+	} else {
+		g.setString(field, "0")
+	}
+}
+
+// params returns the non-empty fields as customer[...] parameters.
+func (c *CustomerProfile) params() map[string]any {
+	g := groupParams{name: "customer", params: make(map[string]any)}
+	g.setString("firstname", c.FirstName)
+	g.setString("lastname", c.LastName)
+	g.setTime("birthday", c.Birthday)
+	g.setString("sex", c.Sex)
+	g.setString("username", c.Username)
+	g.setString("address", c.Address)
+	g.setString("city", c.City)
+	g.setString("state", c.State)
+	g.setString("zip", c.Zip)
+	g.setString("country", c.Country)
+	return g.params
+}
+
+// params returns the non-empty fields as history[...] parameters.
+func (h *UserHistory) params() map[string]any {
+	g := groupParams{name: "history", params: make(map[string]any)}
+	g.setTime("registration_date", h.RegistrationDate)
+	g.setString("registration_country", h.RegistrationCountry)
+	g.setString("registration_ip", h.RegistrationIP)
+	g.setString("registration_email", h.RegistrationEmail)
+	g.setBool("registration_email_verified", h.RegistrationEmailVerified)
+	g.setString("registration_name", h.RegistrationName)
+	g.setString("registration_lastname", h.RegistrationLastName)
+	g.setString("registration_source", h.RegistrationSource)
+	g.setString("membership", h.Membership)
+	g.setTime("membership_date", h.MembershipDate)
+	g.setInt("logins_number", h.LoginsNumber)
+	g.setInt("payments_number", h.PaymentsNumber)
+	if h.PaymentsAmount.Sign() != 0 {
+		g.setString("payments_amount", h.PaymentsAmount.String())
+	}
+	return g.params
+}
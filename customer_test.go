@@ -0,0 +1,101 @@
+// customer_test.go
+package paymentwall
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWidget_CustomerAndHistoryParams(t *testing.T) {
+	verified := true
+	w := NewWidget(NewClient("k", "s", APIVC), "u", "p1", nil, nil)
+	w.Customer = &CustomerProfile{
+		FirstName: "Ada",
+		LastName:  "Lovelace",
+		Birthday:  time.Date(1990, 12, 10, 0, 0, 0, 0, time.UTC),
+		Country:   "GB",
+	}
+	w.History = &UserHistory{
+		RegistrationDate:          time.Unix(1600000000, 0),
+		RegistrationEmailVerified: &verified,
+		Membership:                "gold",
+		PaymentsNumber:            3,
+		PaymentsAmount:            NewDecimal(4250, 2),
+	}
+	params, err := w.GetParams()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{
+		"customer[firstname]":                  "Ada",
+		"customer[lastname]":                   "Lovelace",
+		"customer[birthday]":                   "660787200",
+		"customer[country]":                    "GB",
+		"history[registration_date]":           "1600000000",
+		"history[registration_email_verified]": "1",
+		"history[membership]":                  "gold",
+		"history[payments_number]":             "3",
+		"history[payments_amount]":             "42.50",
+	}
+	for k, v := range want {
+		if params[k] != v {
+			t.Errorf("params[%s] = %v; want %v", k, params[k], v)
+		}
+	}
+	for _, k := range []string{"customer[sex]", "history[logins_number]", "history[membership_date]"} {
+		if _, ok := params[k]; ok {
+			t.Errorf("params[%s] set for empty field", k)
+		}
+	}
+
+	// the same data hand-encoded into ExtraParams signs identically
+	hand := NewWidget(NewClient("k", "s", APIVC), "u", "p1", nil, want)
+	handParams, err := hand.GetParams()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if params["sign"] != handParams["sign"] {
+		t.Errorf("sign = %v; want %v as with hand-encoded keys", params["sign"], handParams["sign"])
+	}
+
+	// time zones do not change the encoded timestamps
+	w.Customer.Birthday = w.Customer.Birthday.In(time.FixedZone("UTC-8", -8*3600))
+	again, _ := w.GetParams()
+	if again["sign"] != params["sign"] {
+		t.Errorf("sign changed with the birthday's time zone")
+	}
+}
+
+func TestWidget_CustomerParamsCannotBeOverridden(t *testing.T) {
+	w := NewWidget(NewClient("k", "s", APIVC), "u", "p1", nil, map[string]any{"customer[firstname]": "Eve"})
+	w.Customer = &CustomerProfile{FirstName: "Ada"}
+	if _, err := w.GetParams(); !errors.Is(err, ErrReservedParam) {
+		t.Errorf("GetParams = %v; want ErrReservedParam", err)
+	}
+
+	// nested values are checked after flattening
+	w.ExtraParams = map[string]any{"customer": map[string]any{"firstname": "Eve"}}
+	_, err := w.GetParams()
+	var rpe *ReservedParamError
+	if !errors.As(err, &rpe) || rpe.Name != "customer[firstname]" {
+		t.Errorf("GetParams nested override = %v; want *ReservedParamError{customer[firstname]}", err)
+	}
+	if _, err := w.GetURL(); !errors.Is(err, ErrReservedParam) {
+		t.Errorf("GetURL nested override = %v; want ErrReservedParam", err)
+	}
+
+	// two extra params flattening to the same key collide too
+	w.Customer = nil
+	w.ExtraParams = map[string]any{"customer": map[string]any{"nickname": "a"}, "customer[nickname]": "b"}
+	if _, err := w.GetParams(); !errors.Is(err, ErrReservedParam) {
+		t.Errorf("GetParams duplicate flattened key = %v; want ErrReservedParam", err)
+	}
+
+	// other customer fields may still be hand-encoded, also in strict mode
+	w.ExtraParams = map[string]any{"customer[nickname]": "ada"}
+	w.Strict = true
+	if _, err := w.GetParams(); err != nil {
+		t.Errorf("GetParams strict customer[nickname] = %v; want nil", err)
+	}
+}
@@ -0,0 +1,176 @@
+// Package paymentwall provides a Go SDK for interacting with the Paymentwall APIs.
+package paymentwall
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Decimal is an exact decimal number, stored as an unscaled integer and the
+// number of digits after the decimal point. The zero value is 0.
+type Decimal struct {
+	unscaled int64
+	scale    int
+}
+
+// maxDecimalDigits keeps every Decimal within int64.
+const maxDecimalDigits = 18
+
+// NewDecimal returns unscaled × 10^-scale, e.g. NewDecimal(1250, 2) is 12.50.
+func NewDecimal(unscaled int64, scale int) Decimal {
+	if scale < 0 {
+		scale = 0
+	}
+	return Decimal{unscaled: unscaled, scale: scale}
+}
+
+// ParseDecimal parses a plain decimal string such as "12", "-0.5" or "+3.250".
+// Exponents, thousands separators and more than 18 digits are rejected.
+func ParseDecimal(s string) (Decimal, error) {
+	orig := s
+	s = strings.TrimSpace(s)
+	neg := false
+	if s != "" && (s[0] == '-' || s[0] == '+') {
+		neg = s[0] == '-'
+		s = s[1:]
+	}
+	intPart, fracPart := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		intPart, fracPart = s[:i], s[i+1:]
+	}
+	digits := intPart + fracPart
+	if digits == "" || len(digits) > maxDecimalDigits {
+		return Decimal{}, fmt.Errorf("invalid decimal %q", orig)
+	}
+	for _, r := range digits {
+		if r < '0' || r > '9' {
+			return Decimal{}, fmt.Errorf("invalid decimal %q", orig)
+		}
+	}
+	u, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil {
+		return Decimal{}, fmt.Errorf("invalid decimal %q", orig)
+	}
+	if neg {
+		u = -u
+	}
+	return Decimal{unscaled: u, scale: len(fracPart)}, nil
+}
+
+// String formats d in fixed-point notation, keeping its scale: "12.50", "-3".
+func (d Decimal) String() string {
+	neg := d.unscaled < 0
+	abs := d.unscaled
+	if neg {
+		abs = -abs
+	}
+	digits := strconv.FormatInt(abs, 10)
+	if d.scale > 0 {
+		if len(digits) <= d.scale {
+			digits = strings.Repeat("0", d.scale-len(digits)+1) + digits
+		}
+		digits = digits[:len(digits)-d.scale] + "." + digits[len(digits)-d.scale:]
+	}
+	if neg {
+		return "-" + digits
+	}
+	return digits
+}
+
+// Unscaled returns the unscaled integer value.
+func (d Decimal) Unscaled() int64 {
+	return d.unscaled
+}
+
+// Scale returns the number of digits after the decimal point.
+func (d Decimal) Scale() int {
+	return d.scale
+}
+
+// Sign returns -1, 0 or +1.
+func (d Decimal) Sign() int {
+	switch {
+	case d.unscaled < 0:
+		return -1
+	case d.unscaled > 0:
+		return 1
+	}
+	return 0
+}
+
+// Neg returns -d.
+func (d Decimal) Neg() Decimal {
+	return Decimal{unscaled: -d.unscaled, scale: d.scale}
+}
+
+// Abs returns |d|.
+func (d Decimal) Abs() Decimal {
+	if d.unscaled < 0 {
+		return d.Neg()
+	}
+	return d
+}
+
+// Rescale returns d with exactly scale fractional digits. It reports false if
+// that would drop non-zero digits or overflow.
+func (d Decimal) Rescale(scale int) (Decimal, bool) {
+	if scale < 0 {
+		return Decimal{}, false
+	}
+	u := d.unscaled
+	for s := d.scale; s < scale; s++ {
+		if u > math.MaxInt64/10 || u < math.MinInt64/10 {
+			return Decimal{}, false
+		}
+		u *= 10
+	}
+	for s := d.scale; s > scale; s-- {
+		if u%10 != 0 {
+			return Decimal{}, false
+		}
+		u /= 10
+	}
+	return Decimal{unscaled: u, scale: scale}, true
+}
+
+// Int64 returns d as an integer, reporting false if it has a fractional part.
+func (d Decimal) Int64() (int64, bool) {
+	i, ok := d.Rescale(0)
+	return i.unscaled, ok
+}
+
+// Cmp compares d and other, returning -1, 0 or +1.
+func (d Decimal) Cmp(other Decimal) int {
+	scale := d.scale
+	if other.scale > scale {
+		scale = other.scale
+	}
+	a, okA := d.Rescale(scale)
+	b, okB := other.Rescale(scale)
+	if !okA || !okB {
+		// fall back to floating point for values too large to align
+		fa, fb := d.Float64(), other.Float64()
+		switch {
+		case fa < fb:
+			return -1
+		case fa > fb:
+			return 1
+		}
+		return 0
+	}
+	switch {
+	case a.unscaled < b.unscaled:
+		return -1
+	case a.unscaled > b.unscaled:
+		return 1
+	}
+	return 0
+}
+
+// Float64 returns the nearest float64 to d.
+func (d Decimal) Float64() float64 {
+	f, _ := strconv.ParseFloat(d.String(), 64)
+	return f
+}
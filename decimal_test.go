@@ -0,0 +1,55 @@
+// decimal_test.go
+package paymentwall
+
+import "testing"
+
+func TestParseDecimal(t *testing.T) {
+	cases := []struct {
+		in   string
+		want string
+	}{
+		{"12", "12"},
+		{"12.50", "12.50"},
+		{"-0.5", "-0.5"},
+		{"+3.250", "3.250"},
+		{".75", "0.75"},
+		{"-100", "-100"},
+	}
+	for _, tc := range cases {
+		d, err := ParseDecimal(tc.in)
+		if err != nil {
+			t.Errorf("ParseDecimal(%q): %v", tc.in, err)
+			continue
+		}
+		if got := d.String(); got != tc.want {
+			t.Errorf("ParseDecimal(%q) = %s; want %s", tc.in, got, tc.want)
+		}
+	}
+	for _, bad := range []string{"", "-", ".", "1e6", "1,000", "abc", "1.2.3", "1234567890123456789"} {
+		if _, err := ParseDecimal(bad); err == nil {
+			t.Errorf("ParseDecimal(%q): want error", bad)
+		}
+	}
+}
+
+func TestDecimal_Arithmetic(t *testing.T) {
+	d := NewDecimal(-1250, 2)
+	if d.String() != "-12.50" || d.Sign() != -1 || d.Abs().String() != "12.50" {
+		t.Errorf("NewDecimal(-1250, 2) = %s, sign %d, abs %s", d, d.Sign(), d.Abs())
+	}
+	if r, ok := d.Rescale(0); ok {
+		t.Errorf("Rescale(0) of -12.50 = %s; want failure", r)
+	}
+	if r, ok := d.Rescale(3); !ok || r.String() != "-12.500" {
+		t.Errorf("Rescale(3) = %s, %v", r, ok)
+	}
+	if i, ok := NewDecimal(500, 2).Int64(); !ok || i != 5 {
+		t.Errorf("Int64(5.00) = %d, %v", i, ok)
+	}
+	if NewDecimal(5, 0).Cmp(NewDecimal(500, 2)) != 0 || NewDecimal(49, 1).Cmp(NewDecimal(5, 0)) != -1 {
+		t.Error("Cmp returned the wrong result")
+	}
+	if f := NewDecimal(125, 2).Float64(); f != 1.25 {
+		t.Errorf("Float64 = %v; want 1.25", f)
+	}
+}
@@ -0,0 +1,260 @@
+// Package paymentwall provides a Go SDK for interacting with the Paymentwall APIs.
+package paymentwall
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// encodedParam is a flattened parameter with a bracketed key, such as
+// "customer[firstname]" or "ids[0]", and its formatted value.
+type encodedParam struct {
+	key   string
+	value string
+}
+
+// encodeParams flattens params into key/value pairs. Slices and arrays of any
+// element type become key[0], key[1], ...; maps and structs become key[k] in
+// canonical order; all may be nested to any depth. If sorted is set, the pairs are put
+// in canonical order (see lessParamKey), otherwise the top-level order of
+// params is kept, as SigV1 requires.
+func encodeParams(params OrderedParams, sorted bool) []encodedParam {
+	var out []encodedParam
+	for _, p := range params {
+		out = appendEncoded(out, p.Key, p.Value)
+	}
+	if sorted {
+		sort.SliceStable(out, func(i, j int) bool { return lessParamKey(out[i].key, out[j].key) })
+	}
+	return out
+}
+
+// appendEncoded appends key=v to out, expanding slices and maps. Nil values,
+// including nil pointers, are omitted; other scalars are formatted by
+// formatValue.
+func appendEncoded(out []encodedParam, key string, v any) []encodedParam {
+	switch val := v.(type) {
+	case nil:
+		return out
+	case []byte:
+		return append(out, encodedParam{key: key, value: string(val)})
+	case []any:
+		return appendList(out, key, len(val), func(i int) any { return val[i] })
+	case map[string]any:
+		for _, k := range sortedKeys(val) {
+			out = appendEncoded(out, key+"["+k+"]", val[k])
+		}
+		return out
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if rv.IsNil() {
+			return out
+		}
+		if _, ok := v.(fmt.Stringer); !ok {
+			return appendEncoded(out, key, rv.Elem().Interface())
+		}
+	case reflect.Slice, reflect.Array:
+		return appendList(out, key, rv.Len(), func(i int) any { return rv.Index(i).Interface() })
+	case reflect.Map:
+		keys := make([]string, 0, rv.Len())
+		values := make(map[string]any, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			k := fmt.Sprint(iter.Key().Interface())
+			keys = append(keys, k)
+			values[k] = iter.Value().Interface()
+		}
+		sort.Slice(keys, func(i, j int) bool { return lessSegment(keys[i], keys[j]) })
+		for _, k := range keys {
+			out = appendEncoded(out, key+"["+k+"]", values[k])
+		}
+		return out
+	case reflect.Struct:
+		if _, ok := v.(fmt.Stringer); !ok {
+			return appendStruct(out, key, rv)
+		}
+	}
+	return append(out, encodedParam{key: key, value: formatValue(v)})
+}
+
+// appendStruct appends the exported fields of a struct as key[name], in
+// canonical order. name is the field's `pw` tag, or its Go name if untagged;
+// fields tagged `pw:"-"` are skipped. Structs that implement fmt.Stringer,
+// such as Decimal and Money, are scalars and never reach here.
+func appendStruct(out []encodedParam, key string, rv reflect.Value) []encodedParam {
+	rt := rv.Type()
+	fields := make(map[string]any, rt.NumField())
+	names := make([]string, 0, rt.NumField())
+	for i := 0; i < rt.NumField(); i++ {
+		f := rt.Field(i)
+		if f.PkgPath != "" {
+			continue // unexported
+		}
+		name := f.Name
+		if tag, ok := f.Tag.Lookup("pw"); ok {
+			if tag == "-" {
+				continue
+			}
+			if tag != "" {
+				name = tag
+			}
+		}
+		fields[name] = rv.Field(i).Interface()
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return lessSegment(names[i], names[j]) })
+	for _, name := range names {
+		out = appendEncoded(out, key+"["+name+"]", fields[name])
+	}
+	return out
+}
+
+// appendList appends the n items of a list as key[0], key[1], ... Items that
+// encode to nothing, such as nil, are skipped without leaving a gap, matching
+// how indexed pingback parameters are folded back into a list.
+func appendList(out []encodedParam, key string, n int, item func(int) any) []encodedParam {
+	idx := 0
+	for i := 0; i < n; i++ {
+		before := len(out)
+		out = appendEncoded(out, fmt.Sprintf("%s[%d]", key, idx), item(i))
+		if len(out) > before {
+			idx++
+		}
+	}
+	return out
+}
+
+// formatValue is the canonical text of a scalar parameter, shared by signing
+// and URL encoding: booleans are 1 or 0, floats use plain decimal notation
+// without an exponent, e.g. 1000000 rather than 1e+06, and times are Unix
+// seconds.
+func formatValue(v any) string {
+	switch val := v.(type) {
+	case string:
+		return val
+	case bool:
+		if val {
+			return "1"
+		}
+		return "0"
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(val), 'f', -1, 32)
+	case int:
+		return strconv.Itoa(val)
+	case int64:
+		return strconv.FormatInt(val, 10)
+	case time.Time:
+		return strconv.FormatInt(val.Unix(), 10)
+	case fmt.Stringer:
+		return val.String()
+	}
+	// named types such as type Flag bool
+	switch rv := reflect.ValueOf(v); rv.Kind() {
+	case reflect.Bool:
+		return formatValue(rv.Bool())
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(rv.Float(), 'f', -1, rv.Type().Bits())
+	}
+	return fmt.Sprint(v)
+}
+
+// sortedKeys returns the keys of m in canonical segment order.
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return lessSegment(keys[i], keys[j]) })
+	return keys
+}
+
+// splitParamKey splits "a[b][0]" into "a" and ["b", "0"]. A malformed
+// bracket suffix is kept as a single segment.
+func splitParamKey(key string) (string, []string) {
+	open := strings.IndexByte(key, '[')
+	if open <= 0 {
+		return key, nil
+	}
+	base, rest := key[:open], key[open:]
+	var segs []string
+	for s := rest; s != ""; {
+		end := strings.IndexByte(s, ']')
+		if s[0] != '[' || end < 0 {
+			return base, []string{rest}
+		}
+		segs = append(segs, s[1:end])
+		s = s[end+1:]
+	}
+	return base, segs
+}
+
+// lessParamKey orders flattened keys the way Paymentwall sorts parameters:
+// by base name first, so "a[x]" sorts before "a0", then segment by segment.
+func lessParamKey(a, b string) bool {
+	baseA, segsA := splitParamKey(a)
+	baseB, segsB := splitParamKey(b)
+	if baseA != baseB {
+		return baseA < baseB
+	}
+	for i := 0; i < len(segsA) && i < len(segsB); i++ {
+		if segsA[i] != segsB[i] {
+			return lessSegment(segsA[i], segsB[i])
+		}
+	}
+	return len(segsA) < len(segsB)
+}
+
+// lessSegment orders numeric segments numerically, before non-numeric ones,
+// which are ordered lexically.
+func lessSegment(a, b string) bool {
+	numA, numB := isDigits(a), isDigits(b)
+	switch {
+	case numA && numB:
+		a, b = strings.TrimLeft(a, "0"), strings.TrimLeft(b, "0")
+		if len(a) != len(b) {
+			return len(a) < len(b)
+		}
+		return a < b
+	case numA != numB:
+		return numA
+	}
+	return a < b
+}
+
+// isDigits reports whether s is a non-empty string of ASCII digits.
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// encodeQuery builds a URL query string from params in canonical order, so
+// that the URL lists parameters exactly as they were signed.
+func encodeQuery(params map[string]any) string {
+	var q strings.Builder
+	for i, p := range encodeParams(NewOrderedParams(params), true) {
+		if i > 0 {
+			q.WriteByte('&')
+		}
+		q.WriteString(url.QueryEscape(p.key))
+		q.WriteByte('=')
+		q.WriteString(url.QueryEscape(p.value))
+	}
+	return q.String()
+}
@@ -0,0 +1,250 @@
+// encode_test.go
+package paymentwall
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBaseString_NestedValues(t *testing.T) {
+	op := OrderedParams{
+		{Key: "uid", Value: "u"},
+		{Key: "customer", Value: map[string]any{"lastname": "L", "firstname": "F"}},
+		{Key: "ids", Value: []int{7, 8}},
+		{Key: "tags", Value: []string{"x"}},
+		{Key: "deep", Value: map[string]any{"b": []float64{1.5}, "a": map[string]string{"z": "1"}}},
+	}
+	want := "uid=u" +
+		"customer[firstname]=Fcustomer[lastname]=L" +
+		"ids[0]=7ids[1]=8" +
+		"tags[0]=x" +
+		"deep[a][z]=1deep[b][0]=1.5"
+	if got := op.baseString(false); got != want {
+		t.Errorf("baseString(false) = %q; want %q", got, want)
+	}
+	wantSorted := "customer[firstname]=Fcustomer[lastname]=L" +
+		"deep[a][z]=1deep[b][0]=1.5" +
+		"ids[0]=7ids[1]=8" +
+		"tags[0]=xuid=u"
+	if got := op.baseString(true); got != wantSorted {
+		t.Errorf("baseString(true) = %q; want %q", got, wantSorted)
+	}
+}
+
+func TestBaseString_CanonicalOrder(t *testing.T) {
+	flat := map[string]any{"ids[10]": "c", "ids[2]": "b", "ids[0]": "a", "ab0": "z", "ab[x]": "y"}
+	want := "ab[x]=yab0=zids[0]=aids[2]=bids[10]=c"
+	if got := NewOrderedParams(flat).baseString(true); got != want {
+		t.Errorf("baseString(true) = %q; want %q", got, want)
+	}
+}
+
+func TestCalculateSignature_NestedMatchesFlat(t *testing.T) {
+	c := NewClient("k", "secret", APIVC)
+	nested := map[string]any{
+		"uid":      "u",
+		"customer": map[string]any{"firstname": "Ada", "lastname": "Lovelace"},
+		"ids":      []string{"a", "b"},
+	}
+	flat := map[string]any{
+		"uid":                 "u",
+		"customer[firstname]": "Ada",
+		"customer[lastname]":  "Lovelace",
+		"ids":                 []any{"a", "b"},
+	}
+	for _, v := range []SignatureVersion{SigV2, SigV3} {
+		a, err := c.CalculateSignature(nested, v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, _ := c.CalculateSignature(flat, v)
+		if a != b {
+			t.Errorf("v%d: nested signature %s != flat signature %s", v, a, b)
+		}
+	}
+	if want := hashMD5("customer[firstname]=Adacustomer[lastname]=Lovelaceids[0]=aids[1]=buid=usecret"); mustSign(t, c, nested, SigV2) != want {
+		t.Errorf("v2 signature does not match the documented base string")
+	}
+}
+
+func mustSign(t *testing.T, c *Client, params map[string]any, v SignatureVersion) string {
+	t.Helper()
+	sig, err := c.CalculateSignature(params, v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sig
+}
+
+func TestWidget_GetURL_NestedParamsVerify(t *testing.T) {
+	client := NewClient("k", "secret", APIVC)
+	w := NewWidget(client, "u", "p1", nil, map[string]any{
+		"custom": map[string]any{"plan": "pro", "seats": []int{3, 10}},
+	})
+	w.Customer = &CustomerProfile{FirstName: "Ada"}
+	rawURL, err := w.GetURL()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(rawURL, "map%5B") || strings.Contains(rawURL, "%5B3+10%5D") {
+		t.Fatalf("GetURL encoded a Go value: %s", rawURL)
+	}
+	u, _ := url.Parse(rawURL)
+	if !strings.Contains(u.RawQuery, "custom%5Bplan%5D=pro&custom%5Bseats%5D%5B0%5D=3&custom%5Bseats%5D%5B1%5D=10&customer%5Bfirstname%5D=Ada") {
+		t.Errorf("query not in canonical order: %s", u.RawQuery)
+	}
+	params := paramsFromValues(u.Query())
+	sig, _ := params["sign"].(string)
+	if err := client.VerifySignature(params, sig, SigV3); err != nil {
+		t.Errorf("URL params do not verify: %v", err)
+	}
+}
+
+func TestBaseString_Structs(t *testing.T) {
+	type address struct {
+		City string `pw:"city"`
+		Zip  string `pw:"zip"`
+	}
+	type customer struct {
+		FirstName string   `pw:"firstname"`
+		Internal  string   `pw:"-"`
+		Address   *address `pw:"address"`
+		Tags      []string
+		Since     time.Time `pw:"since"`
+		Balance   Decimal   `pw:"balance"`
+		note      string
+	}
+	c := customer{
+		FirstName: "Ada", Internal: "x", Address: &address{City: "London"},
+		Tags: []string{"vip"}, Since: time.Unix(1600000000, 0), Balance: NewDecimal(150, 2), note: "n",
+	}
+	want := "customer[Tags][0]=vipcustomer[address][city]=Londoncustomer[address][zip]=" +
+		"customer[balance]=1.50customer[firstname]=Adacustomer[since]=1600000000"
+	if got := NewOrderedParams(map[string]any{"customer": c}).baseString(true); got != want {
+		t.Errorf("baseString = %q; want %q", got, want)
+	}
+	flat := map[string]any{"customer": map[string]any{
+		"firstname": "Ada", "address": map[string]any{"city": "London", "zip": ""},
+		"Tags": []any{"vip"}, "since": "1600000000", "balance": "1.50",
+	}}
+	if got := NewOrderedParams(flat).baseString(true); got != want {
+		t.Errorf("struct and map encodings differ: %q vs %q", got, want)
+	}
+}
+
+func TestFormatValue(t *testing.T) {
+	type flag bool
+	cases := []struct {
+		v    any
+		want string
+	}{
+		{true, "1"},
+		{false, "0"},
+		{flag(true), "1"},
+		{1e6, "1000000"},
+		{1e21, "1000000000000000000000"},
+		{0.1, "0.1"},
+		{float32(2.5), "2.5"},
+		{1e-7, "0.0000001"},
+		{42, "42"},
+		{int64(-7), "-7"},
+		{uint8(9), "9"},
+		{NewDecimal(1250, 2), "12.50"},
+		{NewMoney(1210, "USD"), "12.10"},
+		{"a b", "a b"},
+	}
+	for _, tc := range cases {
+		if got := formatValue(tc.v); got != tc.want {
+			t.Errorf("formatValue(%#v) = %q; want %q", tc.v, got, tc.want)
+		}
+	}
+}
+
+func TestEncodeParams_NilOmitted(t *testing.T) {
+	var missing *Decimal
+	params := map[string]any{"a": nil, "b": missing, "c": []any{nil, "x", []any{}, "y"}, "d": "1"}
+	if got := NewOrderedParams(params).baseString(true); got != "c[0]=xc[1]=yd=1" {
+		t.Errorf("baseString = %q; want %q", got, "c[0]=xc[1]=yd=1")
+	}
+	if got := encodeQuery(params); got != "c%5B0%5D=x&c%5B1%5D=y&d=1" {
+		t.Errorf("encodeQuery = %q; want %q", got, "c%5B0%5D=x&c%5B1%5D=y&d=1")
+	}
+}
+
+// TestWidget_GetURL_RoundTrip checks that whatever GetParams produces, the
+// URL built from it parses back into params that verify against "sign".
+func TestWidget_GetURL_RoundTrip(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	scalars := []func() any{
+		func() any { return rnd.Intn(2) == 1 },
+		func() any { return rnd.NormFloat64() * math.Pow10(rnd.Intn(30)-10) },
+		func() any { return float32(rnd.Intn(1000)) / 8 },
+		func() any { return rnd.Intn(1e6) - 5e5 },
+		func() any { return int64(rnd.Uint32()) },
+		func() any { return NewDecimal(rnd.Int63n(1e6), rnd.Intn(4)) },
+		func() any { return []string{"", "a b", "x&y=z", "ü", "[0]", "%41"}[rnd.Intn(6)] },
+		func() any { return nil },
+	}
+	var value func(depth int) any
+	value = func(depth int) any {
+		switch n := rnd.Intn(8); {
+		case depth < 2 && n == 0:
+			list := make([]any, rnd.Intn(3))
+			for i := range list {
+				list[i] = value(depth + 1)
+			}
+			return list
+		case depth < 2 && n == 1:
+			m := make(map[string]any)
+			for i := rnd.Intn(3); i > 0; i-- {
+				m[fmt.Sprintf("f%d", rnd.Intn(12))] = value(depth + 1)
+			}
+			return m
+		case depth < 2 && n == 2:
+			return []float64{rnd.Float64() * 1e7, 1e-5}
+		}
+		return scalars[rnd.Intn(len(scalars))]()
+	}
+
+	apis := []APIType{APIVC, APIGoods, APICart}
+	prod, _ := NewProduct("p", 9.99, "USD", "P", ProductTypeFixed, 0, "", false, nil)
+	for i := 0; i < 500; i++ {
+		api := apis[rnd.Intn(len(apis))]
+		client := NewClient("k", "secret", api)
+		var prods []*Product
+		if api != APIVC && rnd.Intn(2) == 1 {
+			prods = []*Product{prod}
+		}
+		extra := make(map[string]any)
+		for j := rnd.Intn(5); j > 0; j-- {
+			extra[fmt.Sprintf("x%d", rnd.Intn(20))] = value(0)
+		}
+		w := NewWidget(client, "u", "p1", prods, extra)
+		if rnd.Intn(2) == 1 {
+			w.Options = &WidgetOptions{Evaluation: true, Lang: "en"}
+		}
+		params, err := w.GetParams()
+		if err != nil {
+			t.Fatal(err)
+		}
+		rawURL, _ := w.GetURL()
+		u, err := url.Parse(rawURL)
+		if err != nil {
+			t.Fatalf("url.Parse(%s): %v", rawURL, err)
+		}
+		parsed := paramsFromValues(u.Query())
+		sig, _ := parsed["sign"].(string)
+		sv := SigV3
+		if api == APICart {
+			sv = SigV2
+		}
+		if err := client.VerifySignature(parsed, sig, sv); err != nil {
+			t.Fatalf("round trip %d: %v\nparams: %#v\nurl: %s", i, err, params, rawURL)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+// Package paymentwall provides a Go SDK for interacting with the Paymentwall APIs.
+package paymentwall
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Sentinel errors, usable with errors.Is.
+var (
+	ErrMissingParam                = errors.New("missing parameter")
+	ErrInvalidParam                = errors.New("invalid parameter")
+	ErrIPNotWhitelisted            = errors.New("IP address is not whitelisted")
+	ErrSignatureMismatch           = errors.New("wrong signature")
+	ErrEmptySecret                 = errors.New("secret key cannot be empty")
+	ErrUnsupportedSignatureVersion = errors.New("unsupported signature version")
+	ErrInvalidProduct              = errors.New("invalid product")
+	ErrUnknownProduct              = errors.New("unknown product")
+	ErrReservedParam               = errors.New("reserved parameter")
+	ErrUnknownParam                = errors.New("unknown parameter")
+)
+
+// MissingParamError reports a required pingback parameter that is absent.
+// It matches ErrMissingParam with errors.Is.
+type MissingParamError struct {
+	Name string
+}
+
+func (e *MissingParamError) Error() string {
+	return fmt.Sprintf("Parameter %s is missing", e.Name)
+}
+
+// Is reports whether target is ErrMissingParam.
+func (e *MissingParamError) Is(target error) bool {
+	return target == ErrMissingParam
+}
+
+// InvalidParamError reports a pingback or widget parameter with an invalid value.
+// It matches ErrInvalidParam with errors.Is.
+type InvalidParamError struct {
+	Name  string
+	Value string
+}
+
+func (e *InvalidParamError) Error() string {
+	return fmt.Sprintf("Parameter %s has invalid value %q", e.Name, e.Value)
+}
+
+// Is reports whether target is ErrInvalidParam.
+func (e *InvalidParamError) Is(target error) bool {
+	return target == ErrInvalidParam
+}
+
+// ReservedParamError reports an ExtraParams key that would override a
+// parameter set by the widget itself, such as key, uid or amount. It matches
+// ErrReservedParam with errors.Is.
+type ReservedParamError struct {
+	Name string
+}
+
+func (e *ReservedParamError) Error() string {
+	return fmt.Sprintf("Parameter %s is set by the widget and cannot be overridden", e.Name)
+}
+
+// Is reports whether target is ErrReservedParam.
+func (e *ReservedParamError) Is(target error) bool {
+	return target == ErrReservedParam
+}
+
+// UnknownParamError reports an ExtraParams key that is not a documented widget
+// parameter while Widget.Strict is set. It matches ErrUnknownParam with
+// errors.Is.
+type UnknownParamError struct {
+	Name string
+}
+
+func (e *UnknownParamError) Error() string {
+	return fmt.Sprintf("Parameter %s is not a documented widget parameter", e.Name)
+}
+
+// Is reports whether target is ErrUnknownParam.
+func (e *UnknownParamError) Is(target error) bool {
+	return target == ErrUnknownParam
+}
+
+// SignatureMismatchError reports that a signature did not match the one
+// calculated with the given version. It matches ErrSignatureMismatch with
+// errors.Is.
+type SignatureMismatchError struct {
+	Version SignatureVersion
+}
+
+func (e *SignatureMismatchError) Error() string {
+	return fmt.Sprintf("signature mismatch (sign_version %d)", e.Version)
+}
+
+// Is reports whether target is ErrSignatureMismatch.
+func (e *SignatureMismatchError) Is(target error) bool {
+	return target == ErrSignatureMismatch
+}
@@ -0,0 +1,201 @@
+// Package paymentwall provides a Go SDK for interacting with the Paymentwall APIs.
+package paymentwall
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// PingbackFunc handles a validated pingback. Returning an error makes the
+// handler reply with a non-OK response so that Paymentwall retries delivery.
+type PingbackFunc func(ctx context.Context, pb *Pingback) error
+
+// PingbackHandler is an http.Handler that validates incoming pingbacks and
+// dispatches them to the matching callback.
+type PingbackHandler struct {
+	Client          *Client
+	OnDeliver       PingbackFunc // called when IsDeliverable is true
+	OnCancel        PingbackFunc // called when IsCancelable is true
+	OnUnderReview   PingbackFunc // called when IsUnderReview is true
+	SkipIPWhitelist bool         // bypass the IP check (for testing)
+
+	// Ledger, if set on an APIVC client, is credited for deliverable pingbacks
+	// and debited for negative pingbacks once the callback has succeeded. If
+	// Store is also set, a successful callback is recorded before the ledger
+	// is updated, so a retry after a ledger failure does not run it again.
+	Ledger VCLedger
+
+	// OnOther is called for all other types, such as subscription
+	// cancellations. If it is nil, other documented types are acknowledged and
+	// undocumented types are rejected with *UnhandledPingbackTypeError.
+	OnOther PingbackFunc
+
+	// Store, if set, makes the handler idempotent: a pingback whose unique ID
+	// was already processed is acknowledged without running the callbacks.
+	// Concurrent duplicates can still both be delivered.
+	Store          IdempotencyStore
+	IdempotencyTTL time.Duration // how long processed IDs are kept; 0 means DefaultIdempotencyTTL
+
+	// OnError, if set, receives errors that are not sent in the response,
+	// such as a failing callback, which is answered with a fixed 500 body so
+	// that internal details do not leak, or failing to record a pingback that
+	// was delivered.
+	OnError func(r *http.Request, err error)
+}
+
+// NewPingbackHandler initializes a PingbackHandler for the given client.
+func NewPingbackHandler(client *Client) *PingbackHandler {
+	return &PingbackHandler{Client: client}
+}
+
+// ServeHTTP parses GET/POST parameters, validates the pingback and replies
+// "OK" once the matching callback succeeds.
+func (h *PingbackHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	pb, err := NewPingbackFromRequest(h.Client, r)
+	if err != nil {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+	if err := pb.validate(h.SkipIPWhitelist); err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	id := pb.GetPingbackUniqueID()
+	if h.Store != nil {
+		seen, err := h.Store.Seen(r.Context(), id)
+		if err != nil {
+			h.reportError(r, err)
+			http.Error(w, "idempotency store unavailable", http.StatusInternalServerError)
+			return
+		}
+		if seen {
+			writeOK(w)
+			return
+		}
+	}
+
+	if err := h.dispatch(r, pb, id); err != nil {
+		h.reportError(r, err)
+		http.Error(w, "delivery failed", http.StatusInternalServerError)
+		return
+	}
+
+	if h.Store != nil {
+		// The pingback was delivered: reply OK even if recording it fails,
+		// since a retry would deliver it again.
+		if err := h.Store.MarkProcessed(r.Context(), id, h.ttl()); err != nil {
+			h.reportError(r, err)
+		}
+	}
+	writeOK(w)
+}
+
+// ttl returns IdempotencyTTL, or DefaultIdempotencyTTL if it is 0.
+func (h *PingbackHandler) ttl() time.Duration {
+	if h.IdempotencyTTL == 0 {
+		return DefaultIdempotencyTTL
+	}
+	return h.IdempotencyTTL
+}
+
+// reportError passes err to OnError, if set.
+func (h *PingbackHandler) reportError(r *http.Request, err error) {
+	if h.OnError != nil {
+		h.OnError(r, err)
+	}
+}
+
+// writeOK writes the literal "OK" body Paymentwall expects.
+func writeOK(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte("OK"))
+}
+
+// dispatch calls the callback matching the pingback type, then updates the
+// ledger. Documented types without a registered callback are acknowledged.
+// id is the pingback's unique ID, used to record the callback when a ledger
+// update follows.
+func (h *PingbackHandler) dispatch(r *http.Request, pb *Pingback, id string) error {
+	ctx := r.Context()
+	var fn PingbackFunc
+	switch {
+	case pb.IsDeliverable():
+		fn = h.OnDeliver
+	case pb.IsCancelable():
+		fn = h.OnCancel
+	case pb.IsUnderReview():
+		fn = h.OnUnderReview
+	default:
+		fn = h.OnOther
+		if fn == nil {
+			t, err := pb.GetPingbackType()
+			if err != nil {
+				return err
+			}
+			if !t.Known() {
+				return &UnhandledPingbackTypeError{Type: t}
+			}
+		}
+	}
+	// The ledger is updated after the callback, so that a failed delivery
+	// retried by Paymentwall does not credit it twice. A successful callback
+	// is recorded first, so that a ledger failure retried by Paymentwall does
+	// not run the callback twice.
+	track := fn != nil && h.Store != nil && h.updatesLedger(pb)
+	callbackID := id + ":callback"
+	if track {
+		done, err := h.Store.Seen(ctx, callbackID)
+		if err != nil {
+			return err
+		}
+		if done {
+			fn = nil
+		}
+	}
+	if fn != nil {
+		if err := fn(ctx, pb); err != nil {
+			return err
+		}
+		if track {
+			if err := h.Store.MarkProcessed(ctx, callbackID, h.ttl()); err != nil {
+				h.reportError(r, err)
+			}
+		}
+	}
+	return h.updateLedger(ctx, pb)
+}
+
+// updatesLedger reports whether updateLedger changes h.Ledger for pb. Only
+// negative pingbacks reverse an earlier credit. A declined risk review (202)
+// follows an under-review pingback (200), which was never credited, so it
+// must not be debited.
+func (h *PingbackHandler) updatesLedger(pb *Pingback) bool {
+	if h.Ledger == nil || h.Client.APIType != APIVC {
+		return false
+	}
+	return pb.IsDeliverable() || pb.isType(PingbackTypeNegative)
+}
+
+// updateLedger credits or debits h.Ledger for virtual currency pingbacks.
+func (h *PingbackHandler) updateLedger(ctx context.Context, pb *Pingback) error {
+	if !h.updatesLedger(pb) {
+		return nil
+	}
+	amount, err := pb.GetVCAmountDecimal()
+	if err != nil {
+		return err
+	}
+	if pb.IsDeliverable() {
+		return h.Ledger.Credit(ctx, pb.GetUserID(), amount, pb.GetReferenceID())
+	}
+	// cancellations carry a negative amount; debit its magnitude
+	return h.Ledger.Debit(ctx, pb.GetUserID(), amount.Abs(), pb.GetReferenceID())
+}
@@ -0,0 +1,137 @@
+// handler_test.go
+package paymentwall
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// signedPingbackValues returns Goods pingback values signed with SigV2.
+func signedPingbackValues(t *testing.T, cl *Client, pbType string) url.Values {
+	t.Helper()
+	signed := map[string]any{
+		"uid": "u1", "goodsid": "g1", "type": pbType, "ref": "r1",
+		"sign_version": int(SigV2),
+	}
+	sig, err := cl.CalculateSignature(signed, SigV2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return url.Values{
+		"uid": {"u1"}, "goodsid": {"g1"}, "type": {pbType}, "ref": {"r1"},
+		"sign_version": {"2"}, "sig": {sig},
+	}
+}
+
+func TestPingbackHandler_Dispatch(t *testing.T) {
+	cl := NewClient("k", "s", APIGoods)
+	cases := []struct {
+		pbType string
+		want   string
+	}{
+		{"0", "deliver"},
+		{"2", "cancel"},
+		{"200", "review"},
+	}
+	for _, tc := range cases {
+		var got string
+		h := NewPingbackHandler(cl)
+		h.OnDeliver = func(ctx context.Context, pb *Pingback) error { got = "deliver"; return nil }
+		h.OnCancel = func(ctx context.Context, pb *Pingback) error { got = "cancel"; return nil }
+		h.OnUnderReview = func(ctx context.Context, pb *Pingback) error { got = "review"; return nil }
+
+		req := httptest.NewRequest(http.MethodGet, "/pingback?"+signedPingbackValues(t, cl, tc.pbType).Encode(), nil)
+		req.RemoteAddr = "174.36.92.186:4321"
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusOK || rec.Body.String() != "OK" {
+			t.Errorf("type %s: response = %d %q; want 200 OK", tc.pbType, rec.Code, rec.Body.String())
+		}
+		if got != tc.want {
+			t.Errorf("type %s: callback = %q; want %q", tc.pbType, got, tc.want)
+		}
+	}
+}
+
+func TestPingbackHandler_PostForm(t *testing.T) {
+	cl := NewClient("k", "s", APIGoods)
+	delivered := false
+	h := NewPingbackHandler(cl)
+	h.OnDeliver = func(ctx context.Context, pb *Pingback) error {
+		delivered = pb.GetUserID() == "u1"
+		return nil
+	}
+	body := strings.NewReader(signedPingbackValues(t, cl, "0").Encode())
+	req := httptest.NewRequest(http.MethodPost, "/pingback", body)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.RemoteAddr = "216.127.71.5:80"
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if rec.Body.String() != "OK" || !delivered {
+		t.Errorf("POST pingback = %q, delivered = %v; want OK, true", rec.Body.String(), delivered)
+	}
+}
+
+func TestPingbackHandler_CallbackError(t *testing.T) {
+	cl := NewClient("k", "s", APIGoods)
+	h := NewPingbackHandler(cl)
+	h.OnDeliver = func(ctx context.Context, pb *Pingback) error { return errors.New("db down") }
+	var reported error
+	h.OnError = func(r *http.Request, err error) { reported = err }
+
+	req := httptest.NewRequest(http.MethodGet, "/pingback?"+signedPingbackValues(t, cl, "0").Encode(), nil)
+	req.RemoteAddr = "174.36.92.186:4321"
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusInternalServerError || strings.TrimSpace(rec.Body.String()) != "delivery failed" {
+		t.Errorf("response = %d %q; want 500 delivery failed", rec.Code, rec.Body.String())
+	}
+	if reported == nil || reported.Error() != "db down" {
+		t.Errorf("OnError got %v; want the callback error", reported)
+	}
+}
+
+func TestPingbackHandler_Rejects(t *testing.T) {
+	cl := NewClient("k", "s", APIGoods)
+	h := NewPingbackHandler(cl)
+	h.OnDeliver = func(ctx context.Context, pb *Pingback) error {
+		t.Error("OnDeliver called for invalid pingback")
+		return nil
+	}
+
+	// wrong IP
+	req := httptest.NewRequest(http.MethodGet, "/pingback?"+signedPingbackValues(t, cl, "0").Encode(), nil)
+	req.RemoteAddr = "8.8.8.8:1234"
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("non-whitelisted IP: code = %d; want %d", rec.Code, http.StatusForbidden)
+	}
+
+	// wrong signature
+	vals := signedPingbackValues(t, cl, "0")
+	vals.Set("sig", "bad")
+	req = httptest.NewRequest(http.MethodGet, "/pingback?"+vals.Encode(), nil)
+	req.RemoteAddr = "174.36.92.186:4321"
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden || rec.Body.String() == "OK" {
+		t.Errorf("bad signature: response = %d %q; want 403", rec.Code, rec.Body.String())
+	}
+
+	// unsupported method
+	req = httptest.NewRequest(http.MethodPut, "/pingback", nil)
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("PUT: code = %d; want %d", rec.Code, http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,305 @@
+// Package paymentwall provides a Go SDK for interacting with the Paymentwall APIs.
+package paymentwall
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// DefaultIdempotencyTTL is how long PingbackHandler remembers a processed
+// pingback when IdempotencyTTL is not set.
+const DefaultIdempotencyTTL = 30 * 24 * time.Hour
+
+// IdempotencyStore records processed pingbacks, keyed by
+// Pingback.GetPingbackUniqueID, so that retries are not delivered twice.
+// A ttl <= 0 means the record never expires.
+type IdempotencyStore interface {
+	Seen(ctx context.Context, id string) (bool, error)
+	MarkProcessed(ctx context.Context, id string, ttl time.Duration) error
+}
+
+// expiry returns the expiry time for ttl, or the zero time if it never expires.
+func expiry(now time.Time, ttl time.Duration) time.Time {
+	if ttl <= 0 {
+		return time.Time{}
+	}
+	return now.Add(ttl)
+}
+
+// expired reports whether an entry expiring at exp is stale at now.
+func expired(exp, now time.Time) bool {
+	return !exp.IsZero() && !now.Before(exp)
+}
+
+// MemoryIdempotencyStore is an in-process IdempotencyStore. It is safe for
+// concurrent use but forgets everything when the process exits.
+type MemoryIdempotencyStore struct {
+	mu      sync.Mutex
+	entries map[string]time.Time
+	now     func() time.Time
+	marked  int // MarkProcessed calls since the last sweep
+}
+
+// memorySweepMin is the minimum number of MarkProcessed calls between sweeps
+// of a MemoryIdempotencyStore.
+const memorySweepMin = 1024
+
+// NewMemoryIdempotencyStore creates an empty in-memory store.
+func NewMemoryIdempotencyStore() *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{entries: make(map[string]time.Time), now: time.Now}
+}
+
+// Seen reports whether id was processed and has not expired.
+func (s *MemoryIdempotencyStore) Seen(ctx context.Context, id string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	exp, ok := s.entries[id]
+	if ok && expired(exp, s.now()) {
+		delete(s.entries, id)
+		return false, nil
+	}
+	return ok, nil
+}
+
+// MarkProcessed records id for ttl. Expired entries are dropped by a sweep
+// once the calls since the last sweep reach half the number of entries, so
+// the cost of a sweep is spread over the calls before it.
+func (s *MemoryIdempotencyStore) MarkProcessed(ctx context.Context, id string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	s.entries[id] = expiry(now, ttl)
+	if s.marked++; s.marked >= memorySweepMin && s.marked >= len(s.entries)/2 {
+		s.sweep(now)
+	}
+	return nil
+}
+
+// sweep drops expired entries. s.mu must be held.
+func (s *MemoryIdempotencyStore) sweep(now time.Time) {
+	for k, exp := range s.entries {
+		if expired(exp, now) {
+			delete(s.entries, k)
+		}
+	}
+	s.marked = 0
+}
+
+// fileEntry is one line of a FileIdempotencyStore file.
+type fileEntry struct {
+	ID      string    `json:"id"`
+	Expires time.Time `json:"expires"` // zero for never
+}
+
+// FileIdempotencyStore is an IdempotencyStore backed by an append-only file of
+// JSON lines. It keeps all live entries in memory, is safe for concurrent use
+// within one process, and must not be shared between processes.
+type FileIdempotencyStore struct {
+	mem  *MemoryIdempotencyStore
+	path string
+	mu   sync.Mutex
+	f    *os.File
+}
+
+// OpenFileIdempotencyStore loads the store at path, creating it if needed, and
+// compacts away expired entries.
+func OpenFileIdempotencyStore(path string) (*FileIdempotencyStore, error) {
+	s := &FileIdempotencyStore{mem: NewMemoryIdempotencyStore(), path: path}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	if err := s.Compact(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// load reads existing entries from the file, if any. An unterminated last
+// line is what a crash during MarkProcessed leaves behind; it is skipped, and
+// dropped from the file by the Compact that follows. Any other malformed line
+// is an error.
+func (s *FileIdempotencyStore) load() error {
+	f, err := os.Open(s.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	r := bufio.NewReader(f)
+	for n := 1; ; n++ {
+		line, err := r.ReadBytes('\n')
+		if err == io.EOF {
+			return nil // partial or empty last line
+		}
+		if err != nil {
+			return err
+		}
+		var e fileEntry
+		if err := json.Unmarshal(line, &e); err != nil {
+			return fmt.Errorf("idempotency store %s line %d: %w", s.path, n, err)
+		}
+		s.mem.entries[e.ID] = e.Expires
+	}
+}
+
+// Seen reports whether id was processed and has not expired.
+func (s *FileIdempotencyStore) Seen(ctx context.Context, id string) (bool, error) {
+	return s.mem.Seen(ctx, id)
+}
+
+// MarkProcessed records id for ttl and appends it to the file.
+func (s *FileIdempotencyStore) MarkProcessed(ctx context.Context, id string, ttl time.Duration) error {
+	e := fileEntry{ID: id, Expires: expiry(s.mem.now(), ttl)}
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.f.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	s.mem.mu.Lock()
+	s.mem.entries[id] = e.Expires
+	s.mem.mu.Unlock()
+	return nil
+}
+
+// Compact rewrites the file with only the live entries.
+func (s *FileIdempotencyStore) Compact() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tmp := s.path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	s.mem.mu.Lock()
+	s.mem.sweep(s.mem.now())
+	for id, exp := range s.mem.entries {
+		line, _ := json.Marshal(fileEntry{ID: id, Expires: exp})
+		w.Write(append(line, '\n'))
+	}
+	s.mem.mu.Unlock()
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return err
+	}
+
+	if s.f != nil {
+		s.f.Close()
+	}
+	s.f, err = os.OpenFile(s.path, os.O_APPEND|os.O_WRONLY, 0o600)
+	return err
+}
+
+// Close closes the underlying file.
+func (s *FileIdempotencyStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.f.Close()
+}
+
+// Placeholder formats the n-th (1-based) bind parameter of a SQL statement.
+type Placeholder func(n int) string
+
+// SQL placeholder styles.
+var (
+	QuestionPlaceholder Placeholder = func(int) string { return "?" }                     // MySQL, SQLite
+	DollarPlaceholder   Placeholder = func(n int) string { return fmt.Sprintf("$%d", n) } // PostgreSQL
+)
+
+var sqlIdentPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+
+// SQLIdempotencyStore is an IdempotencyStore backed by a database/sql table
+// with columns id (primary key) and expires_at (Unix nanoseconds, 0 for never).
+type SQLIdempotencyStore struct {
+	db          *sql.DB
+	table       string
+	placeholder Placeholder
+	now         func() time.Time
+}
+
+// NewSQLIdempotencyStore creates a store using table in db. A nil placeholder
+// means QuestionPlaceholder.
+func NewSQLIdempotencyStore(db *sql.DB, table string, placeholder Placeholder) (*SQLIdempotencyStore, error) {
+	if !sqlIdentPattern.MatchString(table) {
+		return nil, fmt.Errorf("invalid table name %q", table)
+	}
+	if placeholder == nil {
+		placeholder = QuestionPlaceholder
+	}
+	return &SQLIdempotencyStore{db: db, table: table, placeholder: placeholder, now: time.Now}, nil
+}
+
+// CreateTable creates the table if it does not exist.
+func (s *SQLIdempotencyStore) CreateTable(ctx context.Context) error {
+	_, err := s.db.ExecContext(ctx, fmt.Sprintf(
+		"CREATE TABLE IF NOT EXISTS %s (id VARCHAR(255) PRIMARY KEY, expires_at BIGINT NOT NULL)", s.table))
+	return err
+}
+
+// Seen reports whether id was processed and has not expired.
+func (s *SQLIdempotencyStore) Seen(ctx context.Context, id string) (bool, error) {
+	var exp int64
+	err := s.db.QueryRowContext(ctx, fmt.Sprintf(
+		"SELECT expires_at FROM %s WHERE id = %s", s.table, s.placeholder(1)), id).Scan(&exp)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return exp == 0 || s.now().UnixNano() < exp, nil
+}
+
+// MarkProcessed records id for ttl, replacing any previous record.
+func (s *SQLIdempotencyStore) MarkProcessed(ctx context.Context, id string, ttl time.Duration) (err error) {
+	var exp int64
+	if e := expiry(s.now(), ttl); !e.IsZero() {
+		exp = e.UnixNano()
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	if _, err = tx.ExecContext(ctx, fmt.Sprintf(
+		"DELETE FROM %s WHERE id = %s", s.table, s.placeholder(1)), id); err != nil {
+		return err
+	}
+	if _, err = tx.ExecContext(ctx, fmt.Sprintf(
+		"INSERT INTO %s (id, expires_at) VALUES (%s, %s)", s.table, s.placeholder(1), s.placeholder(2)), id, exp); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Purge deletes expired records.
+func (s *SQLIdempotencyStore) Purge(ctx context.Context) error {
+	_, err := s.db.ExecContext(ctx, fmt.Sprintf(
+		"DELETE FROM %s WHERE expires_at <> 0 AND expires_at <= %s", s.table, s.placeholder(1)), s.now().UnixNano())
+	return err
+}
@@ -0,0 +1,298 @@
+// idempotency_test.go
+package paymentwall
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMemoryIdempotencyStore_TTL(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1000, 0)
+	s := NewMemoryIdempotencyStore()
+	s.now = func() time.Time { return now }
+
+	if seen, _ := s.Seen(ctx, "r_0"); seen {
+		t.Fatal("Seen before MarkProcessed = true")
+	}
+	s.MarkProcessed(ctx, "r_0", time.Minute)
+	s.MarkProcessed(ctx, "forever", 0)
+	if seen, _ := s.Seen(ctx, "r_0"); !seen {
+		t.Error("Seen after MarkProcessed = false")
+	}
+	now = now.Add(time.Minute)
+	if seen, _ := s.Seen(ctx, "r_0"); seen {
+		t.Error("Seen after TTL = true")
+	}
+	if seen, _ := s.Seen(ctx, "forever"); !seen {
+		t.Error("Seen without TTL = false")
+	}
+}
+
+func TestMemoryIdempotencyStore_Sweep(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1000, 0)
+	s := NewMemoryIdempotencyStore()
+	s.now = func() time.Time { return now }
+
+	for i := 0; i < memorySweepMin-1; i++ {
+		s.MarkProcessed(ctx, fmt.Sprintf("old_%d", i), time.Minute)
+	}
+	now = now.Add(time.Hour)
+	s.MarkProcessed(ctx, "new_0", time.Minute)
+	if len(s.entries) != 1 {
+		t.Errorf("entries after sweep = %d; want 1", len(s.entries))
+	}
+	s.MarkProcessed(ctx, "new_1", time.Minute)
+	if s.marked != 1 {
+		t.Errorf("marked = %d; want 1, no sweep until %d more calls", s.marked, memorySweepMin)
+	}
+}
+
+func TestFileIdempotencyStore_Reopen(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "seen.jsonl")
+	s, err := OpenFileIdempotencyStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.MarkProcessed(ctx, "r_0", time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.MarkProcessed(ctx, "old_0", time.Nanosecond); err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+
+	time.Sleep(time.Millisecond)
+	s, err = OpenFileIdempotencyStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if seen, _ := s.Seen(ctx, "r_0"); !seen {
+		t.Error("Seen after reopen = false")
+	}
+	if seen, _ := s.Seen(ctx, "old_0"); seen {
+		t.Error("expired entry survived reopen")
+	}
+}
+
+func TestFileIdempotencyStore_PartialLastLine(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "seen.jsonl")
+	s, err := OpenFileIdempotencyStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.MarkProcessed(ctx, "r_0", time.Hour)
+	s.MarkProcessed(ctx, "r_1", time.Hour)
+	s.Close()
+
+	// a crash in the middle of appending r_1 cuts its line short
+	data, _ := os.ReadFile(path)
+	if err := os.WriteFile(path, data[:len(data)-10], 0o600); err != nil {
+		t.Fatal(err)
+	}
+	s, err = OpenFileIdempotencyStore(path)
+	if err != nil {
+		t.Fatalf("open with partial last line: %v", err)
+	}
+	if seen, _ := s.Seen(ctx, "r_0"); !seen {
+		t.Error("complete entry lost")
+	}
+	if seen, _ := s.Seen(ctx, "r_1"); seen {
+		t.Error("partial entry loaded")
+	}
+	if err := s.MarkProcessed(ctx, "r_2", time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+	if s, err = OpenFileIdempotencyStore(path); err != nil {
+		t.Fatalf("reopen after recovery: %v", err)
+	}
+	s.Close()
+
+	// corruption before the last line is still an error
+	if err := os.WriteFile(path, []byte("{\"id\":\n{\"id\":\"r_0\"}\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenFileIdempotencyStore(path); err == nil {
+		t.Error("open with a corrupt middle line succeeded")
+	}
+}
+
+func TestSQLIdempotencyStore(t *testing.T) {
+	ctx := context.Background()
+	db, err := sql.Open("pwfake", t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := NewSQLIdempotencyStore(db, "x; DROP TABLE y", nil); err == nil {
+		t.Error("expected error on invalid table name")
+	}
+	s, err := NewSQLIdempotencyStore(db, "pw_pingbacks", DollarPlaceholder)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Unix(1000, 0)
+	s.now = func() time.Time { return now }
+	if err := s.CreateTable(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if seen, err := s.Seen(ctx, "r_0"); err != nil || seen {
+		t.Fatalf("Seen before MarkProcessed = %v, %v", seen, err)
+	}
+	if err := s.MarkProcessed(ctx, "r_0", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.MarkProcessed(ctx, "r_0", time.Minute); err != nil {
+		t.Fatalf("MarkProcessed twice: %v", err)
+	}
+	if seen, _ := s.Seen(ctx, "r_0"); !seen {
+		t.Error("Seen after MarkProcessed = false")
+	}
+	now = now.Add(time.Hour)
+	if seen, _ := s.Seen(ctx, "r_0"); seen {
+		t.Error("Seen after TTL = true")
+	}
+	if err := s.Purge(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(fakeTables[t.Name()]); n != 0 {
+		t.Errorf("rows after Purge = %d; want 0", n)
+	}
+}
+
+func TestPingbackHandler_Idempotent(t *testing.T) {
+	cl := NewClient("k", "s", APIGoods)
+	calls := 0
+	h := NewPingbackHandler(cl)
+	h.Store = NewMemoryIdempotencyStore()
+	h.OnDeliver = func(ctx context.Context, pb *Pingback) error {
+		calls++
+		if calls == 1 {
+			return io.ErrUnexpectedEOF // first attempt fails and is retried
+		}
+		return nil
+	}
+	query := "/pingback?" + signedPingbackValues(t, cl, "0").Encode()
+	for i, want := range []string{"", "OK", "OK"} {
+		req := httptest.NewRequest(http.MethodGet, query, nil)
+		req.RemoteAddr = "174.36.92.186:4321"
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if got := rec.Body.String(); (want == "OK") != (got == "OK") {
+			t.Errorf("attempt %d: body = %q; want OK = %v", i, got, want == "OK")
+		}
+	}
+	if calls != 2 {
+		t.Errorf("OnDeliver calls = %d; want 2", calls)
+	}
+}
+
+// fakeTables holds the rows of the pwfake driver, keyed by DSN: id -> expires_at.
+var (
+	fakeMu     sync.Mutex
+	fakeTables = map[string]map[string]int64{}
+)
+
+func init() {
+	sql.Register("pwfake", fakeDriver{})
+}
+
+// fakeDriver understands just the statements issued by SQLIdempotencyStore.
+type fakeDriver struct{}
+
+func (fakeDriver) Open(dsn string) (driver.Conn, error) {
+	fakeMu.Lock()
+	defer fakeMu.Unlock()
+	if fakeTables[dsn] == nil {
+		fakeTables[dsn] = map[string]int64{}
+	}
+	return &fakeConn{dsn: dsn}, nil
+}
+
+type fakeConn struct{ dsn string }
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{conn: c, query: query}, nil
+}
+func (c *fakeConn) Close() error              { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error) { return fakeTx{}, nil }
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+type fakeStmt struct {
+	conn  *fakeConn
+	query string
+}
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	fakeMu.Lock()
+	defer fakeMu.Unlock()
+	rows := fakeTables[s.conn.dsn]
+	switch {
+	case strings.HasPrefix(s.query, "CREATE TABLE"):
+	case strings.HasPrefix(s.query, "INSERT INTO pw_pingbacks (id, expires_at) VALUES ($1, $2)"):
+		if _, dup := rows[args[0].(string)]; dup {
+			return nil, driver.ErrBadConn
+		}
+		rows[args[0].(string)] = args[1].(int64)
+	case strings.HasPrefix(s.query, "DELETE FROM pw_pingbacks WHERE id = $1"):
+		delete(rows, args[0].(string))
+	case strings.HasPrefix(s.query, "DELETE FROM pw_pingbacks WHERE expires_at <> 0 AND expires_at <= $1"):
+		for id, exp := range rows {
+			if exp != 0 && exp <= args[0].(int64) {
+				delete(rows, id)
+			}
+		}
+	default:
+		return nil, driver.ErrSkip
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	if s.query != "SELECT expires_at FROM pw_pingbacks WHERE id = $1" {
+		return nil, driver.ErrSkip
+	}
+	fakeMu.Lock()
+	defer fakeMu.Unlock()
+	r := &fakeRows{}
+	if exp, ok := fakeTables[s.conn.dsn][args[0].(string)]; ok {
+		r.vals = []int64{exp}
+	}
+	return r, nil
+}
+
+type fakeRows struct{ vals []int64 }
+
+func (r *fakeRows) Columns() []string { return []string{"expires_at"} }
+func (r *fakeRows) Close() error      { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.vals) == 0 {
+		return io.EOF
+	}
+	dest[0], r.vals = r.vals[0], r.vals[1:]
+	return nil
+}
@@ -0,0 +1,17 @@
+// Package paymentwall provides a Go SDK for interacting with the Paymentwall APIs.
+package paymentwall
+
+import "context"
+
+// VCLedger keeps virtual currency balances. PingbackHandler drives it for
+// APIVC clients, after the matching callback succeeds: Credit for deliverable
+// pingbacks and Debit for negative pingbacks (type 2). amount is always
+// non-negative for Debit. ref is the Paymentwall reference ID; a credit and
+// its later reversal share the same ref, so implementations that deduplicate
+// retries must key on (ref, operation), not on ref alone. If Credit or Debit
+// fails, the handler replies 500 and Paymentwall retries the pingback; with
+// an IdempotencyStore the callback is not run again on that retry.
+type VCLedger interface {
+	Credit(ctx context.Context, uid string, amount Decimal, ref string) error
+	Debit(ctx context.Context, uid string, amount Decimal, ref string) error
+}
@@ -0,0 +1,156 @@
+// ledger_test.go
+package paymentwall
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+// recordingLedger records ledger operations as strings.
+type recordingLedger struct {
+	ops []string
+}
+
+func (l *recordingLedger) Credit(ctx context.Context, uid string, amount Decimal, ref string) error {
+	l.ops = append(l.ops, fmt.Sprintf("credit %s %s %s", uid, amount, ref))
+	return nil
+}
+
+func (l *recordingLedger) Debit(ctx context.Context, uid string, amount Decimal, ref string) error {
+	l.ops = append(l.ops, fmt.Sprintf("debit %s %s %s", uid, amount, ref))
+	return nil
+}
+
+func TestPingbackHandler_VCLedger(t *testing.T) {
+	cl := NewClient("k", "s", APIVC)
+	ledger := &recordingLedger{}
+	h := NewPingbackHandler(cl)
+	h.Ledger = ledger
+
+	send := func(pbType, amount, ref string) {
+		signed := map[string]any{
+			"uid": "u1", "currency": amount, "type": pbType, "ref": ref, "sign_version": int(SigV2),
+		}
+		sig, _ := cl.CalculateSignature(signed, SigV2)
+		vals := url.Values{
+			"uid": {"u1"}, "currency": {amount}, "type": {pbType}, "ref": {ref},
+			"sign_version": {"2"}, "sig": {sig},
+		}
+		req := httptest.NewRequest(http.MethodGet, "/pingback?"+vals.Encode(), nil)
+		req.RemoteAddr = "174.36.92.186:4321"
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Body.String() != "OK" {
+			t.Errorf("type %s: body = %q; want OK", pbType, rec.Body.String())
+		}
+	}
+	send("0", "100", "r1")
+	send("2", "-100", "r1")
+	send("200", "5", "r2") // under review: no ledger change
+	send("200", "100", "r9")
+	send("202", "-100", "r9") // declined review was never credited: no debit
+
+	want := []string{"credit u1 100 r1", "debit u1 100 r1"}
+	if fmt.Sprint(ledger.ops) != fmt.Sprint(want) {
+		t.Errorf("ledger ops = %v; want %v", ledger.ops, want)
+	}
+}
+
+func TestPingbackHandler_VCLedgerAfterCallback(t *testing.T) {
+	cl := NewClient("k", "s", APIVC)
+	ledger := &recordingLedger{}
+	h := NewPingbackHandler(cl)
+	h.Ledger = ledger
+	fail := true
+	h.OnDeliver = func(ctx context.Context, pb *Pingback) error {
+		if fail {
+			return fmt.Errorf("out of stock")
+		}
+		return nil
+	}
+
+	signed := map[string]any{"uid": "u1", "currency": "100", "type": "0", "ref": "r1", "sign_version": int(SigV2)}
+	sig, _ := cl.CalculateSignature(signed, SigV2)
+	vals := url.Values{
+		"uid": {"u1"}, "currency": {"100"}, "type": {"0"}, "ref": {"r1"},
+		"sign_version": {"2"}, "sig": {sig},
+	}
+	send := func() int {
+		req := httptest.NewRequest(http.MethodGet, "/pingback?"+vals.Encode(), nil)
+		req.RemoteAddr = "174.36.92.186:4321"
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	if code := send(); code != http.StatusInternalServerError {
+		t.Fatalf("failing callback: status = %d; want 500", code)
+	}
+	if len(ledger.ops) != 0 {
+		t.Errorf("ledger ops after failed callback = %v; want none", ledger.ops)
+	}
+	fail = false
+	send() // Paymentwall's retry
+	if want := []string{"credit u1 100 r1"}; fmt.Sprint(ledger.ops) != fmt.Sprint(want) {
+		t.Errorf("ledger ops after retry = %v; want %v", ledger.ops, want)
+	}
+}
+
+// failingLedger fails every operation until ok is set.
+type failingLedger struct {
+	recordingLedger
+	ok bool
+}
+
+func (l *failingLedger) Credit(ctx context.Context, uid string, amount Decimal, ref string) error {
+	if !l.ok {
+		return fmt.Errorf("ledger unavailable")
+	}
+	return l.recordingLedger.Credit(ctx, uid, amount, ref)
+}
+
+func TestPingbackHandler_VCLedgerFailureDoesNotRepeatCallback(t *testing.T) {
+	cl := NewClient("k", "s", APIVC)
+	ledger := &failingLedger{}
+	h := NewPingbackHandler(cl)
+	h.Ledger = ledger
+	h.Store = NewMemoryIdempotencyStore()
+	calls := 0
+	h.OnDeliver = func(ctx context.Context, pb *Pingback) error {
+		calls++
+		return nil
+	}
+
+	signed := map[string]any{"uid": "u1", "currency": "100", "type": "0", "ref": "r1", "sign_version": int(SigV2)}
+	sig, _ := cl.CalculateSignature(signed, SigV2)
+	vals := url.Values{
+		"uid": {"u1"}, "currency": {"100"}, "type": {"0"}, "ref": {"r1"},
+		"sign_version": {"2"}, "sig": {sig},
+	}
+	send := func() int {
+		req := httptest.NewRequest(http.MethodGet, "/pingback?"+vals.Encode(), nil)
+		req.RemoteAddr = "174.36.92.186:4321"
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	for i := 0; i < 3; i++ {
+		if code := send(); code != http.StatusInternalServerError {
+			t.Fatalf("attempt %d with failing ledger: status = %d; want 500", i, code)
+		}
+	}
+	ledger.ok = true
+	if code := send(); code != http.StatusOK {
+		t.Fatalf("retry: status = %d; want 200", code)
+	}
+	send() // duplicate after success
+	if calls != 1 {
+		t.Errorf("OnDeliver called %d times; want 1", calls)
+	}
+	if want := []string{"credit u1 100 r1"}; fmt.Sprint(ledger.ops) != fmt.Sprint(want) {
+		t.Errorf("ledger ops = %v; want %v", ledger.ops, want)
+	}
+}
@@ -0,0 +1,77 @@
+// Package paymentwall provides a Go SDK for interacting with the Paymentwall APIs.
+package paymentwall
+
+import (
+	"fmt"
+	"math"
+)
+
+// Money is an amount in the minor units of an ISO 4217 currency, e.g.
+// Money{Minor: 1210, Currency: "USD"} is 12.10 USD and
+// Money{Minor: 1210, Currency: "JPY"} is 1210 JPY.
+type Money struct {
+	Minor    int64  // amount in minor units
+	Currency string // ISO 4217 code, e.g. "USD"
+}
+
+// CurrencyExponent returns the number of decimal digits of the currency's
+// minor unit: 2 for USD, 0 for JPY, 3 for KWD. Unknown currencies use 2.
+func CurrencyExponent(currency string) int {
+	if c, ok := LookupCurrency(currency); ok {
+		return c.MinorUnits
+	}
+	return 2
+}
+
+// NewMoney returns minor units of currency.
+func NewMoney(minor int64, currency string) Money {
+	return Money{Minor: minor, Currency: currency}
+}
+
+// MoneyFromDecimal converts d to currency. It fails if d has more decimal
+// digits than the currency allows, e.g. 1.5 JPY or 1.005 USD.
+func MoneyFromDecimal(d Decimal, currency string) (Money, error) {
+	r, ok := d.Rescale(CurrencyExponent(currency))
+	if !ok {
+		return Money{}, fmt.Errorf("amount %s is not representable in %s", d, currency)
+	}
+	return Money{Minor: r.Unscaled(), Currency: currency}, nil
+}
+
+// MoneyFromFloat rounds amount to the currency's minor unit.
+func MoneyFromFloat(amount float64, currency string) Money {
+	scale := math.Pow10(CurrencyExponent(currency))
+	return Money{Minor: int64(math.Round(amount * scale)), Currency: currency}
+}
+
+// ParseMoney parses a decimal amount, such as one received in a pingback,
+// in the given currency.
+func ParseMoney(amount, currency string) (Money, error) {
+	d, err := ParseDecimal(amount)
+	if err != nil {
+		return Money{}, err
+	}
+	return MoneyFromDecimal(d, currency)
+}
+
+// Decimal returns the amount in major units.
+func (m Money) Decimal() Decimal {
+	return NewDecimal(m.Minor, CurrencyExponent(m.Currency))
+}
+
+// String returns the canonical amount with exactly the currency's number of
+// decimals and no currency code, e.g. "12.10", "1210" (JPY) or "1.500" (KWD).
+// This is the format sent to Paymentwall and used in signatures.
+func (m Money) String() string {
+	return m.Decimal().String()
+}
+
+// Float64 returns the amount in major units as a float64.
+func (m Money) Float64() float64 {
+	return m.Decimal().Float64()
+}
+
+// IsZero reports whether the amount is zero.
+func (m Money) IsZero() bool {
+	return m.Minor == 0
+}
@@ -0,0 +1,38 @@
+// money_test.go
+package paymentwall
+
+import "testing"
+
+func TestMoney_String(t *testing.T) {
+	cases := []struct {
+		m    Money
+		want string
+	}{
+		{MoneyFromFloat(12.1, "USD"), "12.10"},
+		{MoneyFromFloat(1210.4, "JPY"), "1210"},
+		{MoneyFromFloat(1.5, "KWD"), "1.500"},
+		{NewMoney(-5, "EUR"), "-0.05"},
+		{NewMoney(7, "BHD"), "0.007"},
+	}
+	for _, tc := range cases {
+		if got := tc.m.String(); got != tc.want {
+			t.Errorf("%+v.String() = %q; want %q", tc.m, got, tc.want)
+		}
+	}
+}
+
+func TestParseMoney(t *testing.T) {
+	m, err := ParseMoney("9.99", "USD")
+	if err != nil || m != NewMoney(999, "USD") {
+		t.Errorf("ParseMoney(9.99 USD) = %+v, %v", m, err)
+	}
+	m, err = ParseMoney("500", "JPY")
+	if err != nil || m.Minor != 500 {
+		t.Errorf("ParseMoney(500 JPY) = %+v, %v", m, err)
+	}
+	for _, tc := range [][2]string{{"1.5", "JPY"}, {"1.005", "USD"}, {"abc", "USD"}} {
+		if _, err := ParseMoney(tc[0], tc[1]); err == nil {
+			t.Errorf("ParseMoney(%s %s): want error", tc[0], tc[1])
+		}
+	}
+}
@@ -3,40 +3,29 @@ package paymentwall
 
 import (
 	"fmt"
+	"math"
 	"net"
+	"net/http"
+	"net/url"
+	"sort"
 	"strconv"
 	"strings"
-	"sync"
 )
 
-var (
-	_ipWhitelist map[string]struct{}
-	_cidrNet     *net.IPNet
-	_once        sync.Once
-)
-
-func initWhitelist() {
-	// 1) seed fixed addresses
-	_ipWhitelist = map[string]struct{}{
-		"174.36.92.186": {},
-		"174.36.96.66":  {},
-		"174.36.92.187": {},
-		"174.36.92.192": {},
-		"174.37.14.28":  {},
-	}
-	// 2) parse the /24 CIDR
-	_, cidr, err := net.ParseCIDR("216.127.71.0/24")
-	if err == nil {
-		_cidrNet = cidr
-	}
-}
-
 // Pingback represents a Paymentwall webhook notification validator.
 type Pingback struct {
 	Client    *Client
 	Params    map[string]any
 	IPAddress string
-	Errors    []string
+	// Errors holds the messages of the failures found by Validate and
+	// ValidateErr, in the same form as earlier releases.
+	//
+	// Deprecated: use the error returned by ValidateErr, which can be
+	// inspected with errors.Is and errors.As.
+	Errors []string
+	// MatchedKeyIndex is the index in Client.SecretKeys of the key that
+	// verified the signature, or -1 if the signature has not been verified.
+	MatchedKeyIndex int
 }
 
 // NewPingback constructs a Pingback with given params and source IP.
@@ -46,69 +35,193 @@ func NewPingback(client *Client, params map[string]any, ip string) *Pingback {
 		Params:    params,
 		IPAddress: ip,
 		Errors:    []string{},
+
+		MatchedKeyIndex: -1,
+	}
+}
+
+// NewPingbackFromValues constructs a Pingback from decoded query or form values.
+// Indexed keys such as "goodsid[0]" and "goodsid[1]" are folded into an ordered
+// []any under "goodsid", ordered by numeric index, if the indices run from 0
+// without gaps or repeats; otherwise they are kept as sent.
+func NewPingbackFromValues(client *Client, vals url.Values, ip string) *Pingback {
+	return NewPingback(client, paramsFromValues(vals), ip)
+}
+
+// NewPingbackFromRequest constructs a Pingback from the GET/POST parameters of r.
+// The source IP is the host part of r.RemoteAddr, or the first untrusted hop of
+// the forwarding headers when client.TrustedProxies is set.
+func NewPingbackFromRequest(client *Client, r *http.Request) (*Pingback, error) {
+	if err := r.ParseForm(); err != nil {
+		return nil, fmt.Errorf("parse pingback request: %w", err)
+	}
+	return NewPingbackFromValues(client, r.Form, client.TrustedProxies.ClientIP(r)), nil
+}
+
+// indexedValue is a single value of an indexed key like "goodsid[3]".
+type indexedValue struct {
+	index int
+	value string
+}
+
+// paramsFromValues converts url.Values into pingback params. Single values become
+// strings, repeated plain keys become []any, and indexed keys are folded into []any.
+// Indexed keys are kept as they are if their name is also used as a plain key
+// or with other brackets, like "name[0][field]", or if the indices are sparse
+// or repeated, like "name[0]" and "name[2]": folding those would renumber
+// them, and the signature covers the original keys.
+func paramsFromValues(vals url.Values) map[string]any {
+	params := make(map[string]any, len(vals))
+	indexed := make(map[string][]indexedValue)
+	noFold := make(map[string]bool)
+	for k := range vals {
+		if _, _, ok := splitIndexedKey(k); !ok {
+			noFold[paramBaseName(k)] = true
+		}
+	}
+	for k, vs := range vals {
+		if name, idx, ok := splitIndexedKey(k); ok && !noFold[name] {
+			for _, v := range vs {
+				indexed[name] = append(indexed[name], indexedValue{index: idx, value: v})
+			}
+		}
+	}
+	for name, items := range indexed {
+		sort.SliceStable(items, func(i, j int) bool { return items[i].index < items[j].index })
+		if !denseIndices(items) {
+			noFold[name] = true
+			delete(indexed, name)
+		}
+	}
+	for k, vs := range vals {
+		if name, _, ok := splitIndexedKey(k); ok && !noFold[name] {
+			continue
+		}
+		if len(vs) == 1 {
+			params[k] = vs[0]
+			continue
+		}
+		list := make([]any, len(vs))
+		for i, v := range vs {
+			list[i] = v
+		}
+		params[k] = list
+	}
+	for name, items := range indexed {
+		list := make([]any, len(items))
+		for i, item := range items {
+			list[i] = item.value
+		}
+		params[name] = list
+	}
+	return params
+}
+
+// denseIndices reports whether sorted items are numbered 0, 1, 2, ... with
+// no gaps or repeats, optionally followed by "name[]" items, which are
+// appended in order.
+func denseIndices(items []indexedValue) bool {
+	for i, item := range items {
+		if item.index != i && item.index != math.MaxInt {
+			return false
+		}
+	}
+	return true
+}
+
+// splitIndexedKey splits "name[3]" into ("name", 3). Keys with empty brackets,
+// like "name[]", are given an index that sorts after any explicit index.
+func splitIndexedKey(key string) (string, int, bool) {
+	open := strings.IndexByte(key, '[')
+	if open <= 0 || !strings.HasSuffix(key, "]") {
+		return "", 0, false
+	}
+	inner := key[open+1 : len(key)-1]
+	if inner == "" {
+		return key[:open], math.MaxInt, true
 	}
+	idx, err := strconv.Atoi(inner)
+	if err != nil || idx < 0 || strings.HasPrefix(inner, "+") {
+		return "", 0, false
+	}
+	return key[:open], idx, true
+}
+
+// remoteIP returns the host part of r.RemoteAddr.
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
 }
 
 // Validate runs parameter, IP whitelist, and signature checks.
 // skipIPWhitelist allows bypassing the IP check (for testing).
+// It is a compatibility wrapper around ValidateErr.
 func (p *Pingback) Validate(skipIPWhitelist bool) bool {
-	if !p.isParamsValid() {
+	return p.validate(skipIPWhitelist) == nil
+}
+
+// ValidateErr runs parameter, IP whitelist, and signature checks and returns
+// the first failure. The error matches ErrMissingParam, ErrIPNotWhitelisted or
+// ErrSignatureMismatch with errors.Is, and *MissingParamError or
+// *SignatureMismatchError with errors.As. All failures are also recorded in
+// p.Errors as messages.
+func (p *Pingback) ValidateErr() error {
+	return p.validate(false)
+}
+
+// validate implements Validate and ValidateErr.
+func (p *Pingback) validate(skipIPWhitelist bool) error {
+	if err := p.checkParams(); err != nil {
 		p.Errors = append(p.Errors, "Missing parameters")
-		return false
+		return err
 	}
 	if !skipIPWhitelist && !p.isIPAddressValid() {
 		p.Errors = append(p.Errors, "IP address is not whitelisted")
-		return false
+		return ErrIPNotWhitelisted
 	}
-	if !p.isSignatureValid() {
+	if err := p.checkSignature(); err != nil {
 		p.Errors = append(p.Errors, "Wrong signature")
-		return false
+		return err
 	}
-	return true
+	return nil
 }
 
-// isParamsValid checks for required fields and records missing ones.
+// requiredParams lists the parameters every pingback must carry.
 // VC needs ["uid","currency","type","ref","sig"];
 // Goods/Cart need ["uid","goodsid","type","ref","sig"].
-func (p *Pingback) isParamsValid() bool {
-	var required []string
-	if p.Client.APIType == APIVC {
-		required = []string{"uid", "currency", "type", "ref", "sig"}
-	} else {
-		required = []string{"uid", "goodsid", "type", "ref", "sig"}
+func requiredParams(api APIType) []string {
+	if api == APIVC {
+		return []string{"uid", "currency", "type", "ref", "sig"}
 	}
+	return []string{"uid", "goodsid", "type", "ref", "sig"}
+}
 
-	valid := true
-	for _, key := range required {
+// checkParams checks for required fields and records missing ones.
+// It returns the first *MissingParamError.
+func (p *Pingback) checkParams() error {
+	var first error
+	for _, key := range requiredParams(p.Client.APIType) {
 		if _, ok := p.Params[key]; !ok {
-			p.Errors = append(p.Errors, fmt.Sprintf("Parameter %s is missing", key))
-			valid = false
+			err := &MissingParamError{Name: key}
+			p.Errors = append(p.Errors, err.Error())
+			if first == nil {
+				first = err
+			}
 		}
 	}
-	return valid
+	return first
 }
 
-// isIPAddressValid checks if the source IP is in Paymentwall's whitelist.
+// isIPAddressValid checks if the source IP is in the client's allowlist.
 func (p *Pingback) isIPAddressValid() bool {
-	// one-time init
-	_once.Do(initWhitelist)
-
-	// quick exact match
-	if _, ok := _ipWhitelist[p.IPAddress]; ok {
-		return true
-	}
-	// if we parsed the CIDR, check containment
-	if _cidrNet != nil {
-		ip := net.ParseIP(p.IPAddress)
-		if ip != nil && _cidrNet.Contains(ip) {
-			return true
-		}
-	}
-	return false
+	return p.Client.ipAllowlist().Contains(p.IPAddress)
 }
 
-// isSignatureValid recalculates and compares the signature.
-func (p *Pingback) isSignatureValid() bool {
+// checkSignature recalculates and compares the signature.
+func (p *Pingback) checkSignature() error {
 	// 1) Determine sign_version
 	sv := SigV1
 	if val, ok := p.Params["sign_version"]; ok {
@@ -120,90 +233,179 @@ func (p *Pingback) isSignatureValid() bool {
 		sv = SigV2
 	}
 
-	// 2) Build a copy of params without "sig"
-	signedParams := make(map[string]any, len(p.Params))
-	for k, v := range p.Params {
-		if k == "sig" {
-			continue
-		}
-		signedParams[k] = v
-	}
-
-	// 3) Inject sign_version for v2/v3 
-	if sv != SigV1 {
-		signedParams["sign_version"] = int(sv)
+	// 2) Delegate to Client.verifySignature (handles V1, V2, V3 hashing and key rotation)
+	idx, err := p.Client.verifySignature(p.signedParams(sv), p.param("sig"), sv)
+	if err != nil {
+		return err
 	}
+	p.MatchedKeyIndex = idx
+	return nil
+}
 
-	// 4) For SigV1, filter down to only the required fields
+// signedParams returns the params covered by the pingback signature.
+// SigV1 signs a fixed list of fields in Paymentwall's documented order, with
+// absent fields signed as empty values; v2/v3 sign every param except "sig",
+// including sign_version.
+func (p *Pingback) signedParams(sv SignatureVersion) OrderedParams {
 	if sv == SigV1 {
-		var fields []string
-		switch p.Client.APIType {
-		case APIVC:
-			fields = []string{"uid", "currency", "type", "ref"}
-		case APIGoods:
-			fields = []string{"uid", "goodsid", "slength", "speriod", "type", "ref"}
-		default: // Cart
-			fields = []string{"uid", "goodsid", "type", "ref"}
-		}
-		filtered := make(map[string]any, len(fields))
+		fields := sigV1Fields(p.Client.APIType)
+		signed := make(OrderedParams, 0, len(fields))
 		for _, f := range fields {
-			if v, ok := signedParams[f]; ok {
-				filtered[f] = v
+			// absent fields keep their position, signed as empty
+			v, ok := p.Params[f]
+			if !ok || v == nil {
+				v = ""
 			}
+			signed = append(signed, Param{Key: f, Value: v})
+		}
+		return signed
+	}
+	signed := make(map[string]any, len(p.Params))
+	for k, v := range p.Params {
+		if k == "sig" {
+			continue
 		}
-		signedParams = filtered
+		signed[k] = v
 	}
+	signed["sign_version"] = int(sv)
+	return NewOrderedParams(signed)
+}
 
-	// 5) Delegate to Client.CalculateSignature (handles V1, V2, V3 hashing)
-	sigCalc, err := p.Client.CalculateSignature(signedParams, sv)
-	if err != nil {
-		return false
+// sigV1Fields lists the pingback fields signed by SigV1, in signing order.
+func sigV1Fields(api APIType) []string {
+	switch api {
+	case APIVC:
+		return []string{"uid", "currency", "type", "ref"}
+	case APIGoods:
+		return []string{"uid", "goodsid", "slength", "speriod", "type", "ref"}
+	default: // Cart
+		return []string{"uid", "goodsid", "type", "ref"}
 	}
+}
 
-	// 6) Compare to the original
-	return fmt.Sprint(p.Params["sig"]) == sigCalc
+// param returns the parameter named key as a string, or "" if it is absent.
+func (p *Pingback) param(key string) string {
+	return paramString(p.Params[key])
+}
+
+// paramString formats a parameter value, mapping nil to "".
+func paramString(v any) string {
+	if v == nil {
+		return ""
+	}
+	return fmt.Sprint(v)
 }
 
 // GetUserID returns the "uid" parameter.
 func (p *Pingback) GetUserID() string {
-	return fmt.Sprint(p.Params["uid"])
+	return p.param("uid")
 }
 
 // GetType returns the pingback type as int.
 func (p *Pingback) GetType() (int, error) {
-	return strconv.Atoi(fmt.Sprint(p.Params["type"]))
+	return strconv.Atoi(p.param("type"))
+}
+
+// GetPingbackType returns the pingback type as a PingbackType.
+func (p *Pingback) GetPingbackType() (PingbackType, error) {
+	t, err := p.GetType()
+	if err != nil {
+		return 0, fmt.Errorf("invalid pingback type %q: %w", p.param("type"), err)
+	}
+	return PingbackType(t), nil
+}
+
+// isType reports whether the pingback type is one of types.
+func (p *Pingback) isType(types ...PingbackType) bool {
+	t, err := p.GetPingbackType()
+	if err != nil {
+		return false
+	}
+	for _, want := range types {
+		if t == want {
+			return true
+		}
+	}
+	return false
 }
 
 // GetVCAmount returns the "currency" parameter for virtual currency.
 func (p *Pingback) GetVCAmount() string {
-	return fmt.Sprint(p.Params["currency"])
+	return p.param("currency")
+}
+
+// GetVCAmountDecimal parses the "currency" parameter, which may be negative for
+// cancellations and fractional for some virtual currencies.
+func (p *Pingback) GetVCAmountDecimal() (Decimal, error) {
+	s, ok := p.optionalParam("currency")
+	if !ok {
+		return Decimal{}, &MissingParamError{Name: "currency"}
+	}
+	d, err := ParseDecimal(s)
+	if err != nil {
+		return Decimal{}, &InvalidParamError{Name: "currency", Value: s}
+	}
+	return d, nil
+}
+
+// GetVCAmountInt parses the "currency" parameter as a whole, possibly
+// negative, amount. A fractional amount is an *InvalidParamError.
+func (p *Pingback) GetVCAmountInt() (int64, error) {
+	d, err := p.GetVCAmountDecimal()
+	if err != nil {
+		return 0, err
+	}
+	i, ok := d.Int64()
+	if !ok {
+		return 0, &InvalidParamError{Name: "currency", Value: d.String()}
+	}
+	return i, nil
 }
 
 // GetProductID returns the product ID from "goodsid".
 func (p *Pingback) GetProductID() string {
-	return fmt.Sprint(p.Params["goodsid"])
+	return p.param("goodsid")
 }
 
-// GetProduct reconstructs a Product from pingback parameters.
+// GetProduct returns the product of a Goods API pingback. If the client has a
+// Catalog, the catalog entry for goodsid is returned, or an error matching
+// ErrUnknownProduct if there is none. Otherwise a stub Product without price is
+// reconstructed from the pingback parameters.
 func (p *Pingback) GetProduct() (*Product, error) {
-	length, _ := strconv.Atoi(fmt.Sprint(p.Params["slength"]))
-	periodType := fmt.Sprint(p.Params["speriod"])
+	if c := p.catalog(); c != nil {
+		return c.lookup(p.param("goodsid"))
+	}
+	length, _ := strconv.Atoi(p.param("slength"))
+	periodType := p.param("speriod")
 	t := ProductTypeFixed
 	if length > 0 {
 		t = ProductTypeSubscription
+	} else {
+		length, periodType = 0, ""
 	}
 	return NewProduct(
-		fmt.Sprint(p.Params["goodsid"]),
+		p.param("goodsid"),
 		0, "", "", t, length, periodType, false, nil,
 	)
 }
 
-// GetProducts returns a slice of Products for Cart API.
+// GetProducts returns a slice of Products for Cart API. If the client has a
+// Catalog, the catalog entries are returned and an unknown ID is an error
+// matching ErrUnknownProduct; otherwise stub fixed products are built.
 func (p *Pingback) GetProducts() ([]*Product, error) {
 	var prods []*Product
+	c := p.catalog()
 	if vals, ok := p.Params["goodsid"].([]any); ok {
 		for _, v := range vals {
 			id := fmt.Sprint(v)
+			if c != nil {
+				prod, err := c.lookup(id)
+				if err != nil {
+					return nil, err
+				}
+				prods = append(prods, prod)
+				continue
+			}
 			if prod, err := NewProduct(id, 0, "", "", ProductTypeFixed, 0, "", false, nil); err == nil {
 				prods = append(prods, prod)
 			}
@@ -212,22 +414,52 @@ func (p *Pingback) GetProducts() ([]*Product, error) {
 	return prods, nil
 }
 
+// catalog returns the client's Catalog, if any.
+func (p *Pingback) catalog() *Catalog {
+	if p.Client == nil {
+		return nil
+	}
+	return p.Client.Catalog
+}
+
 // IsDeliverable returns true if pingback type indicates delivery.
 func (p *Pingback) IsDeliverable() bool {
-	t, err := p.GetType()
-	return err == nil && (t == 0 || t == 1 || t == 201)
+	return p.isType(PingbackTypeRegular, PingbackTypeGoodwill, PingbackTypeRiskReviewAccepted)
 }
 
 // IsCancelable returns true if pingback type indicates cancellation.
 func (p *Pingback) IsCancelable() bool {
-	t, err := p.GetType()
-	return err == nil && (t == 2 || t == 202)
+	return p.isType(PingbackTypeNegative, PingbackTypeRiskReviewDeclined)
 }
 
 // IsUnderReview returns true if pingback type indicates under review.
 func (p *Pingback) IsUnderReview() bool {
-	t, err := p.GetType()
-	return err == nil && t == 200
+	return p.isType(PingbackTypeRiskUnderReview)
+}
+
+// IsSubscriptionCancelled returns true if a recurring subscription was cancelled.
+func (p *Pingback) IsSubscriptionCancelled() bool {
+	return p.isType(PingbackTypeSubscriptionCancelled)
+}
+
+// IsSubscriptionExpired returns true if a non-recurring subscription expired.
+func (p *Pingback) IsSubscriptionExpired() bool {
+	return p.isType(PingbackTypeSubscriptionExpired)
+}
+
+// IsPaymentFailed returns true if a recurring subscription payment failed.
+func (p *Pingback) IsPaymentFailed() bool {
+	return p.isType(PingbackTypeSubscriptionPaymentFailed)
+}
+
+// IsRiskReviewAccepted returns true if a payment under review was accepted.
+func (p *Pingback) IsRiskReviewAccepted() bool {
+	return p.isType(PingbackTypeRiskReviewAccepted)
+}
+
+// IsRiskReviewDeclined returns true if a payment under review was declined.
+func (p *Pingback) IsRiskReviewDeclined() bool {
+	return p.isType(PingbackTypeRiskReviewDeclined)
 }
 
 // ErrorSummary returns accumulated errors.
@@ -237,7 +469,7 @@ func (p *Pingback) ErrorSummary() string {
 
 // GetReferenceID returns the "ref" parameter.
 func (p *Pingback) GetReferenceID() string {
-	return fmt.Sprint(p.Params["ref"])
+	return p.param("ref")
 }
 
 // GetPingbackUniqueID returns a unique ID composed of ref and type, e.g. "REF123_0".
@@ -2,6 +2,12 @@
 package paymentwall
 
 import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
 	"strconv"
 	"testing"
 	"strings"
@@ -57,3 +63,221 @@ func TestPingback_SigV2_Valid(t *testing.T) {
 		t.Errorf("Validate valid SigV2 = false; %s", pb.ErrorSummary())
 	}
 }
+
+func TestNewPingbackFromValues_IndexedKeys(t *testing.T) {
+	cl := NewClient("k", "sec", APICart)
+	vals := url.Values{
+		"uid": {"u"}, "type": {"0"}, "ref": {"r"}, "sign_version": {"2"},
+	}
+	// indices out of lexical order: 0, 1, 2, ..., 10
+	var ids []any
+	for i := 10; i >= 0; i-- {
+		vals.Set(fmt.Sprintf("goodsid[%d]", i), fmt.Sprintf("p%d", i))
+	}
+	for i := 0; i <= 10; i++ {
+		ids = append(ids, fmt.Sprintf("p%d", i))
+	}
+	signed := map[string]any{
+		"uid": "u", "type": "0", "ref": "r", "sign_version": int(SigV2),
+		"goodsid": ids,
+	}
+	sig, _ := cl.CalculateSignature(signed, SigV2)
+	vals.Set("sig", sig)
+
+	pb := NewPingbackFromValues(cl, vals, "174.36.92.186")
+	if !reflect.DeepEqual(pb.Params["goodsid"], ids) {
+		t.Errorf("goodsid = %v; want %v", pb.Params["goodsid"], ids)
+	}
+	if !pb.Validate(false) {
+		t.Errorf("Validate = false; %s", pb.ErrorSummary())
+	}
+	prods, _ := pb.GetProducts()
+	if len(prods) != 11 || prods[10].ID != "p10" {
+		t.Errorf("GetProducts = %v; want 11 products ending with p10", prods)
+	}
+}
+
+func TestNewPingbackFromValues_SparseIndicesKept(t *testing.T) {
+	cl := NewClient("k", "sec", APICart)
+	for _, extra := range []url.Values{
+		{"goodsid[0]": {"a"}, "goodsid[2]": {"b"}},
+		{"goodsid[0]": {"a", "b"}},
+		{"goodsid[1]": {"a"}},
+	} {
+		vals := url.Values{"uid": {"u"}, "type": {"0"}, "ref": {"r"}, "sign_version": {"2"}}
+		signed := map[string]any{"uid": "u", "type": "0", "ref": "r", "sign_version": "2"}
+		for k, vs := range extra {
+			vals[k] = vs
+			if len(vs) == 1 {
+				signed[k] = vs[0]
+			} else {
+				signed[k] = []any{vs[0], vs[1]}
+			}
+		}
+		sig, _ := cl.CalculateSignature(signed, SigV2)
+		vals.Set("sig", sig)
+
+		pb := NewPingbackFromValues(cl, vals, "174.36.92.186")
+		if _, ok := pb.Params["goodsid"]; ok {
+			t.Errorf("%v: goodsid folded to %v; want keys kept as sent", extra, pb.Params["goodsid"])
+		}
+		if err := cl.VerifySignature(pb.Params, sig, SigV2); err != nil {
+			t.Errorf("%v: %v", extra, err)
+		}
+		renumbered := map[string]any{"uid": "u", "type": "0", "ref": "r", "sign_version": "2", "goodsid": []any{"a", "b"}}
+		if forged, _ := cl.CalculateSignature(renumbered, SigV2); len(extra) == 2 && cl.VerifySignature(pb.Params, forged, SigV2) == nil {
+			t.Errorf("%v: verified against the renumbered list", extra)
+		}
+	}
+}
+
+func TestNewPingbackFromRequest(t *testing.T) {
+	cl := NewClient("k", "s", APICart)
+	req := httptest.NewRequest(http.MethodGet, "/pb?uid=u&goodsid[]=a&goodsid[]=b&name[x]=y", nil)
+	req.RemoteAddr = "174.36.92.186:5555"
+	pb, err := NewPingbackFromRequest(cl, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pb.IPAddress != "174.36.92.186" {
+		t.Errorf("IPAddress = %q; want 174.36.92.186", pb.IPAddress)
+	}
+	if !reflect.DeepEqual(pb.Params["goodsid"], []any{"a", "b"}) {
+		t.Errorf("goodsid = %v; want [a b]", pb.Params["goodsid"])
+	}
+	if pb.Params["name[x]"] != "y" {
+		t.Errorf("name[x] = %v; want y", pb.Params["name[x]"])
+	}
+}
+
+func TestPingback_SigV1_KnownSignatures(t *testing.T) {
+	cases := []struct {
+		name   string
+		api    APIType
+		params map[string]any
+	}{
+		{"vc", APIVC, map[string]any{
+			"uid": "user1", "currency": "100", "type": "0", "ref": "t1",
+			"sig": "3f315424cc4e5de47740348502afddf5",
+		}},
+		{"goods subscription", APIGoods, map[string]any{
+			"uid": "user1", "goodsid": "gold_plan", "slength": "1", "speriod": "month",
+			"type": "0", "ref": "t2", "sig": "bd96db14360313181d773a7ff579c5c8",
+		}},
+		// slength and speriod are absent and signed as empty values
+		{"goods one-time", APIGoods, map[string]any{
+			"uid": "user1", "goodsid": "one_time", "type": "0", "ref": "t3",
+			"sig": "4c4caaf2c7e80746627b5841d03d15a8",
+		}},
+		{"cart", APICart, map[string]any{
+			"uid": "user1", "goodsid": []any{"g1", "g2"}, "type": "0", "ref": "t4",
+			"sign_version": "1", "sig": "1a6f737d18197d1d6d798c72b7a111f0",
+		}},
+	}
+	for _, tc := range cases {
+		// repeat to catch any dependence on map iteration order
+		for i := 0; i < 20; i++ {
+			pb := NewPingback(NewClient("k", "secret", tc.api), tc.params, "174.36.92.186")
+			if !pb.Validate(false) {
+				t.Fatalf("%s: Validate = false; %s", tc.name, pb.ErrorSummary())
+			}
+		}
+	}
+}
+
+func TestPingback_MatchedKeyIndex(t *testing.T) {
+	cl := NewClient("k", "old", APIGoods)
+	params := map[string]any{
+		"uid": "u", "goodsid": "g", "type": "0", "ref": "r", "sign_version": "2",
+	}
+	sig, _ := cl.CalculateSignature(map[string]any{
+		"uid": "u", "goodsid": "g", "type": "0", "ref": "r", "sign_version": int(SigV2),
+	}, SigV2)
+	params["sig"] = sig
+
+	cl.RotateSecretKey("new")
+	pb := NewPingback(cl, params, "174.36.92.186")
+	if pb.MatchedKeyIndex != -1 {
+		t.Errorf("MatchedKeyIndex before Validate = %d; want -1", pb.MatchedKeyIndex)
+	}
+	if !pb.Validate(false) {
+		t.Fatalf("Validate with rotated key = false; %s", pb.ErrorSummary())
+	}
+	if pb.MatchedKeyIndex != 1 {
+		t.Errorf("MatchedKeyIndex = %d; want 1 (old key)", pb.MatchedKeyIndex)
+	}
+}
+
+func TestPingback_ValidateErr(t *testing.T) {
+	cl := NewClient("k", "s", APIGoods)
+
+	pb := NewPingback(cl, map[string]any{"uid": "u"}, "174.36.92.186")
+	err := pb.ValidateErr()
+	var missing *MissingParamError
+	if !errors.Is(err, ErrMissingParam) || !errors.As(err, &missing) || missing.Name != "goodsid" {
+		t.Errorf("ValidateErr missing params = %v; want *MissingParamError{goodsid}", err)
+	}
+	want := []string{
+		"Parameter goodsid is missing", "Parameter type is missing", "Parameter ref is missing",
+		"Parameter sig is missing", "Missing parameters",
+	}
+	if !reflect.DeepEqual(pb.Errors, want) {
+		t.Errorf("Errors = %q; want %q as in earlier releases", pb.Errors, want)
+	}
+
+	params := map[string]any{"uid": "u", "goodsid": "g", "type": "0", "ref": "r", "sig": "x"}
+	pb = NewPingback(cl, params, "8.8.8.8")
+	if err := pb.ValidateErr(); !errors.Is(err, ErrIPNotWhitelisted) {
+		t.Errorf("ValidateErr bad IP = %v; want ErrIPNotWhitelisted", err)
+	}
+
+	pb = NewPingback(cl, params, "174.36.92.186")
+	err = pb.ValidateErr()
+	var mismatch *SignatureMismatchError
+	if !errors.Is(err, ErrSignatureMismatch) || !errors.As(err, &mismatch) || mismatch.Version != SigV1 {
+		t.Errorf("ValidateErr bad sig = %v; want *SignatureMismatchError{SigV1}", err)
+	}
+	if pb.Validate(true) {
+		t.Error("Validate bad sig = true; want false")
+	}
+	if want := "Wrong signature\nWrong signature"; pb.ErrorSummary() != want {
+		t.Errorf("ErrorSummary = %q; want %q", pb.ErrorSummary(), want)
+	}
+}
+
+func TestPingback_AccessorsWithoutParams(t *testing.T) {
+	pb := NewPingback(NewClient("k", "s", APIGoods), map[string]any{}, "")
+	if got := pb.GetUserID(); got != "" {
+		t.Errorf("GetUserID = %q; want empty", got)
+	}
+	if got := pb.GetProductID(); got != "" {
+		t.Errorf("GetProductID = %q; want empty", got)
+	}
+}
+
+func TestPingback_GetVCAmount(t *testing.T) {
+	cl := NewClient("k", "s", APIVC)
+	pb := NewPingback(cl, map[string]any{"currency": "-250"}, "")
+	if n, err := pb.GetVCAmountInt(); err != nil || n != -250 {
+		t.Errorf("GetVCAmountInt = %d, %v; want -250", n, err)
+	}
+	pb = NewPingback(cl, map[string]any{"currency": "12.75"}, "")
+	if d, err := pb.GetVCAmountDecimal(); err != nil || d.String() != "12.75" {
+		t.Errorf("GetVCAmountDecimal = %s, %v; want 12.75", d, err)
+	}
+	if _, err := pb.GetVCAmountInt(); !errors.Is(err, ErrInvalidParam) {
+		t.Errorf("GetVCAmountInt fractional = %v; want ErrInvalidParam", err)
+	}
+	pb = NewPingback(cl, map[string]any{"currency": "ten"}, "")
+	if _, err := pb.GetVCAmountDecimal(); !errors.Is(err, ErrInvalidParam) {
+		t.Errorf("GetVCAmountDecimal garbage = %v; want ErrInvalidParam", err)
+	}
+}
+
+func TestParamsFromValues_MixedBracketsStayFlat(t *testing.T) {
+	params := paramsFromValues(url.Values{"x[0][f]": {"a"}, "x[1]": {"b"}, "y": {"c"}, "y[0]": {"d"}})
+	want := map[string]any{"x[0][f]": "a", "x[1]": "b", "y": "c", "y[0]": "d"}
+	if !reflect.DeepEqual(params, want) {
+		t.Errorf("paramsFromValues = %v; want %v", params, want)
+	}
+}
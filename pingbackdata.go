@@ -0,0 +1,143 @@
+// Package paymentwall provides a Go SDK for interacting with the Paymentwall APIs.
+package paymentwall
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// PingbackData is the typed content of a pingback, as returned by
+// Pingback.Decode. Optional fields are nil when the parameter is absent; a
+// string parameter that is present but empty points to "". Numeric and boolean
+// parameters that are present but empty are invalid.
+type PingbackData struct {
+	UID      string
+	Type     PingbackType
+	Ref      string
+	Currency *Decimal            // virtual currency amount (VC); negative for cancellations
+	GoodsIDs []string            // product IDs: one for Goods, one or more for Cart
+	SLength  *int                // subscription period length
+	SPeriod  *string             // subscription period type: day, week, month, year
+	IsTest   *bool               // whether the payment was made in test mode
+	Reason   *CancellationReason // cancellation reason
+	Custom   map[string]string   // all other parameters except sig and sign_version
+}
+
+// GoodsID returns the first product ID, or "" if there is none.
+func (d *PingbackData) GoodsID() string {
+	if len(d.GoodsIDs) == 0 {
+		return ""
+	}
+	return d.GoodsIDs[0]
+}
+
+// pingbackDataParams are decoded into dedicated PingbackData fields.
+var pingbackDataParams = map[string]bool{
+	"uid": true, "type": true, "ref": true, "currency": true, "goodsid": true,
+	"slength": true, "speriod": true, "is_test": true, "reason": true,
+	"sig": true, "sign_version": true,
+}
+
+// Decode parses the pingback parameters into a PingbackData. Missing required
+// parameters are reported as *MissingParamError, and unparsable values as
+// *InvalidParamError. Decode does not validate the pingback; call ValidateErr
+// first.
+func (p *Pingback) Decode() (*PingbackData, error) {
+	for _, key := range requiredParams(p.Client.APIType) {
+		if _, ok := p.Params[key]; !ok && key != "sig" {
+			return nil, &MissingParamError{Name: key}
+		}
+	}
+
+	d := &PingbackData{
+		UID:    p.param("uid"),
+		Ref:    p.param("ref"),
+		Custom: make(map[string]string),
+	}
+	t, err := p.GetType()
+	if err != nil {
+		return nil, &InvalidParamError{Name: "type", Value: p.param("type")}
+	}
+	d.Type = PingbackType(t)
+
+	if _, ok := p.optionalParam("currency"); ok {
+		amount, err := p.GetVCAmountDecimal()
+		if err != nil {
+			return nil, err
+		}
+		d.Currency = &amount
+	}
+	switch v := p.Params["goodsid"].(type) {
+	case nil:
+	case []any:
+		d.GoodsIDs = make([]string, len(v))
+		for i, id := range v {
+			d.GoodsIDs[i] = paramString(id)
+		}
+	default:
+		d.GoodsIDs = []string{paramString(v)}
+	}
+	if d.SLength, err = p.optionalInt("slength"); err != nil {
+		return nil, err
+	}
+	if s, ok := p.Params["speriod"]; ok {
+		period := paramString(s)
+		switch period {
+		case "", PeriodDay, PeriodWeek, PeriodMonth, PeriodYear:
+		default:
+			return nil, &InvalidParamError{Name: "speriod", Value: period}
+		}
+		d.SPeriod = &period
+	}
+	if s, ok := p.optionalParam("is_test"); ok {
+		isTest, err := strconv.ParseBool(s)
+		if err != nil {
+			return nil, &InvalidParamError{Name: "is_test", Value: s}
+		}
+		d.IsTest = &isTest
+	}
+	if _, ok := p.optionalParam("reason"); ok {
+		reason, err := p.GetReason()
+		if err != nil {
+			return nil, err
+		}
+		d.Reason = &reason
+	}
+
+	for k, v := range p.Params {
+		if pingbackDataParams[k] {
+			continue
+		}
+		if list, ok := v.([]any); ok {
+			for i, item := range list {
+				d.Custom[fmt.Sprintf("%s[%d]", k, i)] = paramString(item)
+			}
+			continue
+		}
+		d.Custom[k] = paramString(v)
+	}
+	return d, nil
+}
+
+// optionalParam returns the parameter named key and whether it is present,
+// even if empty.
+func (p *Pingback) optionalParam(key string) (string, bool) {
+	v, ok := p.Params[key]
+	if !ok || v == nil {
+		return "", false
+	}
+	return paramString(v), true
+}
+
+// optionalInt parses an optional integer parameter.
+func (p *Pingback) optionalInt(key string) (*int, error) {
+	s, ok := p.optionalParam(key)
+	if !ok {
+		return nil, nil
+	}
+	i, err := strconv.Atoi(s)
+	if err != nil {
+		return nil, &InvalidParamError{Name: key, Value: s}
+	}
+	return &i, nil
+}
@@ -0,0 +1,93 @@
+// pingbackdata_test.go
+package paymentwall
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestPingback_Decode_VC(t *testing.T) {
+	pb := NewPingback(NewClient("k", "s", APIVC), map[string]any{
+		"uid": "u1", "currency": "-12.5", "type": "2", "ref": "r1", "reason": "1",
+		"is_test": "1", "sig": "x", "sign_version": "2", "custom_field": "abc",
+	}, "")
+	d, err := pb.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.UID != "u1" || d.Ref != "r1" || d.Type != PingbackTypeNegative {
+		t.Errorf("Decode = %+v", d)
+	}
+	if d.Currency == nil || d.Currency.String() != "-12.5" {
+		t.Errorf("Currency = %v; want -12.5", d.Currency)
+	}
+	if d.IsTest == nil || !*d.IsTest || d.Reason == nil || *d.Reason != ReasonChargeback {
+		t.Errorf("IsTest = %v, Reason = %v", d.IsTest, d.Reason)
+	}
+	if d.GoodsIDs != nil || d.SLength != nil || d.SPeriod != nil {
+		t.Errorf("absent fields decoded: %+v", d)
+	}
+	if !reflect.DeepEqual(d.Custom, map[string]string{"custom_field": "abc"}) {
+		t.Errorf("Custom = %v", d.Custom)
+	}
+}
+
+func TestPingback_Decode_GoodsAndCart(t *testing.T) {
+	pb := NewPingback(NewClient("k", "s", APIGoods), map[string]any{
+		"uid": "", "goodsid": "plan", "slength": "3", "speriod": "month", "type": "0", "ref": "r",
+	}, "")
+	d, err := pb.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.UID != "" || d.GoodsID() != "plan" || *d.SLength != 3 || *d.SPeriod != PeriodMonth {
+		t.Errorf("Goods Decode = %+v", d)
+	}
+
+	pb = NewPingback(NewClient("k", "s", APICart), map[string]any{
+		"uid": "u", "goodsid": []any{"a", "b"}, "type": "0", "ref": "r", "speriod": "",
+		"extra": []any{"x", "y"},
+	}, "")
+	d, err = pb.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(d.GoodsIDs, []string{"a", "b"}) {
+		t.Errorf("GoodsIDs = %v", d.GoodsIDs)
+	}
+	if d.SPeriod == nil || *d.SPeriod != "" {
+		t.Errorf("present but empty speriod = %v; want pointer to empty", d.SPeriod)
+	}
+	if d.Custom["extra[1]"] != "y" {
+		t.Errorf("Custom = %v", d.Custom)
+	}
+}
+
+func TestPingback_Decode_Errors(t *testing.T) {
+	pb := NewPingback(NewClient("k", "s", APIVC), map[string]any{"uid": "u"}, "")
+	if _, err := pb.Decode(); !errors.Is(err, ErrMissingParam) {
+		t.Errorf("Decode missing = %v; want ErrMissingParam", err)
+	}
+	if got := pb.GetReferenceID(); got != "" {
+		t.Errorf("GetReferenceID absent = %q; want empty", got)
+	}
+
+	pb = NewPingback(NewClient("k", "s", APIVC), map[string]any{
+		"uid": "u", "currency": "lots", "type": "0", "ref": "r",
+	}, "")
+	var invalid *InvalidParamError
+	if _, err := pb.Decode(); !errors.As(err, &invalid) || invalid.Name != "currency" {
+		t.Errorf("Decode garbage currency = %v; want *InvalidParamError{currency}", err)
+	}
+
+	// present but empty numeric and boolean params are invalid, not absent
+	for _, name := range []string{"currency", "slength", "is_test", "reason"} {
+		params := map[string]any{"uid": "u", "currency": "10", "type": "0", "ref": "r"}
+		params[name] = ""
+		pb = NewPingback(NewClient("k", "s", APIVC), params, "")
+		if _, err := pb.Decode(); !errors.As(err, &invalid) || invalid.Name != name {
+			t.Errorf("Decode empty %s = %v; want *InvalidParamError{%s}", name, err, name)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+// Package paymentwall provides a Go SDK for interacting with the Paymentwall APIs.
+package paymentwall
+
+import (
+	"context"
+	"fmt"
+)
+
+// PingbackType is the value of the pingback "type" parameter.
+type PingbackType int
+
+const (
+	PingbackTypeRegular                   PingbackType = 0   // payment completed
+	PingbackTypeGoodwill                  PingbackType = 1   // credit given by customer service
+	PingbackTypeNegative                  PingbackType = 2   // payment reversed, e.g. chargeback
+	PingbackTypeSubscriptionCancelled     PingbackType = 12  // recurring subscription cancelled
+	PingbackTypeSubscriptionExpired       PingbackType = 13  // non-recurring subscription expired
+	PingbackTypeSubscriptionPaymentFailed PingbackType = 14  // recurring payment failed
+	PingbackTypeRiskUnderReview           PingbackType = 200 // payment held for risk review
+	PingbackTypeRiskReviewAccepted        PingbackType = 201 // risk review accepted the payment
+	PingbackTypeRiskReviewDeclined        PingbackType = 202 // risk review declined the payment
+	PingbackTypeRiskAuthorizationVoided   PingbackType = 203 // authorization voided after risk review
+)
+
+var pingbackTypeNames = map[PingbackType]string{
+	PingbackTypeRegular:                   "regular",
+	PingbackTypeGoodwill:                  "goodwill",
+	PingbackTypeNegative:                  "negative",
+	PingbackTypeSubscriptionCancelled:     "subscription cancelled",
+	PingbackTypeSubscriptionExpired:       "subscription expired",
+	PingbackTypeSubscriptionPaymentFailed: "subscription payment failed",
+	PingbackTypeRiskUnderReview:           "risk under review",
+	PingbackTypeRiskReviewAccepted:        "risk review accepted",
+	PingbackTypeRiskReviewDeclined:        "risk review declined",
+	PingbackTypeRiskAuthorizationVoided:   "risk authorization voided",
+}
+
+// String returns a readable name, or "unknown (N)" for undocumented types.
+func (t PingbackType) String() string {
+	if name, ok := pingbackTypeNames[t]; ok {
+		return name
+	}
+	return fmt.Sprintf("unknown (%d)", int(t))
+}
+
+// Known reports whether t is one of the documented pingback types.
+func (t PingbackType) Known() bool {
+	_, ok := pingbackTypeNames[t]
+	return ok
+}
+
+// UnhandledPingbackTypeError reports a pingback type that SwitchType has no
+// case for, including undocumented types.
+type UnhandledPingbackTypeError struct {
+	Type PingbackType
+}
+
+func (e *UnhandledPingbackTypeError) Error() string {
+	return fmt.Sprintf("unhandled pingback type: %s", e.Type)
+}
+
+// PingbackTypeCases holds one callback per pingback type for SwitchType.
+// Nil callbacks are unhandled.
+type PingbackTypeCases struct {
+	Regular                   PingbackFunc
+	Goodwill                  PingbackFunc
+	Negative                  PingbackFunc
+	SubscriptionCancelled     PingbackFunc
+	SubscriptionExpired       PingbackFunc
+	SubscriptionPaymentFailed PingbackFunc
+	RiskUnderReview           PingbackFunc
+	RiskReviewAccepted        PingbackFunc
+	RiskReviewDeclined        PingbackFunc
+	RiskAuthorizationVoided   PingbackFunc
+	// Default, if set, handles types without their own case, including
+	// undocumented ones.
+	Default PingbackFunc
+}
+
+// forType returns the callback for t, falling back to Default.
+func (c PingbackTypeCases) forType(t PingbackType) PingbackFunc {
+	var fn PingbackFunc
+	switch t {
+	case PingbackTypeRegular:
+		fn = c.Regular
+	case PingbackTypeGoodwill:
+		fn = c.Goodwill
+	case PingbackTypeNegative:
+		fn = c.Negative
+	case PingbackTypeSubscriptionCancelled:
+		fn = c.SubscriptionCancelled
+	case PingbackTypeSubscriptionExpired:
+		fn = c.SubscriptionExpired
+	case PingbackTypeSubscriptionPaymentFailed:
+		fn = c.SubscriptionPaymentFailed
+	case PingbackTypeRiskUnderReview:
+		fn = c.RiskUnderReview
+	case PingbackTypeRiskReviewAccepted:
+		fn = c.RiskReviewAccepted
+	case PingbackTypeRiskReviewDeclined:
+		fn = c.RiskReviewDeclined
+	case PingbackTypeRiskAuthorizationVoided:
+		fn = c.RiskAuthorizationVoided
+	}
+	if fn == nil {
+		fn = c.Default
+	}
+	return fn
+}
+
+// SwitchType calls the case matching the pingback type. It returns an
+// *UnhandledPingbackTypeError if no case applies, so that new or unknown
+// types are surfaced instead of silently ignored.
+func (p *Pingback) SwitchType(ctx context.Context, cases PingbackTypeCases) error {
+	t, err := p.GetPingbackType()
+	if err != nil {
+		return err
+	}
+	fn := cases.forType(t)
+	if fn == nil {
+		return &UnhandledPingbackTypeError{Type: t}
+	}
+	return fn(ctx, p)
+}
@@ -0,0 +1,102 @@
+// pingbacktype_test.go
+package paymentwall
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPingbackType_StringKnown(t *testing.T) {
+	if got := PingbackTypeSubscriptionCancelled.String(); got != "subscription cancelled" {
+		t.Errorf("String(12) = %q", got)
+	}
+	if got := PingbackType(99).String(); got != "unknown (99)" {
+		t.Errorf("String(99) = %q", got)
+	}
+	if !PingbackTypeRiskAuthorizationVoided.Known() || PingbackType(99).Known() {
+		t.Error("Known returned the wrong result")
+	}
+}
+
+func TestPingback_TypePredicates(t *testing.T) {
+	cases := []struct {
+		typ  string
+		pred func(*Pingback) bool
+	}{
+		{"12", (*Pingback).IsSubscriptionCancelled},
+		{"13", (*Pingback).IsSubscriptionExpired},
+		{"14", (*Pingback).IsPaymentFailed},
+		{"201", (*Pingback).IsRiskReviewAccepted},
+		{"202", (*Pingback).IsRiskReviewDeclined},
+	}
+	for _, tc := range cases {
+		pb := NewPingback(NewClient("k", "s", APIGoods), map[string]any{"type": tc.typ}, "")
+		if !tc.pred(pb) {
+			t.Errorf("predicate for type %s = false; want true", tc.typ)
+		}
+		other := NewPingback(NewClient("k", "s", APIGoods), map[string]any{"type": "0"}, "")
+		if tc.pred(other) {
+			t.Errorf("predicate for type %s matched type 0", tc.typ)
+		}
+	}
+}
+
+func TestPingback_SwitchType(t *testing.T) {
+	cl := NewClient("k", "s", APIGoods)
+	var got string
+	cases := PingbackTypeCases{
+		SubscriptionExpired: func(ctx context.Context, pb *Pingback) error { got = "expired"; return nil },
+	}
+
+	pb := NewPingback(cl, map[string]any{"type": "13"}, "")
+	if err := pb.SwitchType(context.Background(), cases); err != nil || got != "expired" {
+		t.Errorf("SwitchType(13) = %v, %q; want nil, expired", err, got)
+	}
+
+	var unhandled *UnhandledPingbackTypeError
+	pb = NewPingback(cl, map[string]any{"type": "99"}, "")
+	if err := pb.SwitchType(context.Background(), cases); !errors.As(err, &unhandled) || unhandled.Type != 99 {
+		t.Errorf("SwitchType(99) = %v; want *UnhandledPingbackTypeError", err)
+	}
+
+	cases.Default = func(ctx context.Context, pb *Pingback) error { got = "default"; return nil }
+	if err := pb.SwitchType(context.Background(), cases); err != nil || got != "default" {
+		t.Errorf("SwitchType(99) with Default = %v, %q", err, got)
+	}
+
+	pb = NewPingback(cl, map[string]any{}, "")
+	if err := pb.SwitchType(context.Background(), cases); err == nil {
+		t.Error("SwitchType without type: want error")
+	}
+}
+
+func TestPingbackHandler_OtherTypes(t *testing.T) {
+	cl := NewClient("k", "s", APIGoods)
+	h := NewPingbackHandler(cl)
+	serve := func(pbType string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/pingback?"+signedPingbackValues(t, cl, pbType).Encode(), nil)
+		req.RemoteAddr = "174.36.92.186:4321"
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := serve("12"); rec.Body.String() != "OK" {
+		t.Errorf("documented type without callback = %q; want OK", rec.Body.String())
+	}
+	if rec := serve("99"); rec.Code != http.StatusInternalServerError {
+		t.Errorf("unknown type = %d; want %d", rec.Code, http.StatusInternalServerError)
+	}
+
+	var got PingbackType
+	h.OnOther = func(ctx context.Context, pb *Pingback) error {
+		got, _ = pb.GetPingbackType()
+		return nil
+	}
+	if rec := serve("99"); rec.Body.String() != "OK" || got != 99 {
+		t.Errorf("unknown type with OnOther = %q, type %d; want OK, 99", rec.Body.String(), got)
+	}
+}
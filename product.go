@@ -3,7 +3,6 @@ package paymentwall
 
 import (
 	"fmt"
-	"math"
 )
 
 // ProductType constants
@@ -23,18 +22,21 @@ const (
 // Product represents a one-time or subscription-based item in the Paymentwall SDK.
 type Product struct {
 	ID           string   // Unique identifier for the product
-	Amount       float64  // Price, rounded to two decimals
+	Amount       float64  // Price, rounded to the currency's minor unit
+	Price        Money    // Exact price; if set, Amount must agree with it
 	CurrencyCode string   // ISO currency code, e.g., "USD"
 	Name         string   // Human-readable name
 	Type         string   // Product type: fixed or subscription
 	PeriodLength int      // Subscription period length
 	PeriodType   string   // Subscription period unit: day, week, month, year
 	Recurring    bool     // Whether subscription auto-renews
-	TrialProduct *Product // Trial period product; non-nil only for recurring subscriptions
+	TrialProduct *Product // Trial period product; only allowed on recurring subscriptions
 }
 
-// NewProduct constructs and validates a Product, rounding amount to two decimals.
-// Returns an error if prodType or periodType is invalid.
+// NewProduct constructs and validates a Product, rounding amount to the
+// currency's minor unit (two decimals for USD, none for JPY, three for KWD).
+// currencyCode is upper-cased; an empty code is allowed.
+// Returns an error if the product breaks any of the rules checked by Validate.
 func NewProduct(
 	id string,
 	amount float64,
@@ -44,39 +46,184 @@ func NewProduct(
 	recurring bool,
 	trial *Product,
 ) (*Product, error) {
-	// Validate product type
-	if prodType != ProductTypeFixed && prodType != ProductTypeSubscription {
-		return nil, fmt.Errorf("invalid product type: %s", prodType)
+	// Validate and normalise currency code
+	currencyCode, err := normalizeCurrencyCode(id, currencyCode)
+	if err != nil {
+		return nil, err
 	}
-	// Validate period type if provided
-	if periodType != "" {
-		switch periodType {
-		case PeriodDay, PeriodWeek, PeriodMonth, PeriodYear:
-			// valid
-		default:
-			return nil, fmt.Errorf("invalid period type: %s", periodType)
-		}
-	}
-	// Round amount to two decimals
-	rounded := math.Round(amount*100) / 100
+	// Round amount to the currency's minor unit
+	price := MoneyFromFloat(amount, currencyCode)
 	p := &Product{
 		ID:           id,
-		Amount:       rounded,
+		Amount:       price.Float64(),
+		Price:        price,
 		CurrencyCode: currencyCode,
 		Name:         name,
 		Type:         prodType,
 		PeriodLength: periodLength,
 		PeriodType:   periodType,
 		Recurring:    recurring,
+		TrialProduct: trial,
 	}
-	// Assign trial product only for recurring subscriptions
-	if prodType == ProductTypeSubscription && recurring {
-		p.TrialProduct = trial
+	if err := p.Validate(); err != nil {
+		return nil, err
 	}
 	return p, nil
 }
 
+// Validate checks a Product, including hand-constructed ones, against the
+// rules Paymentwall expects. Errors match ErrInvalidProduct with errors.Is.
+//   - Type is fixed or subscription, and CurrencyCode is a known ISO 4217 code
+//     that agrees with Price.
+//   - If Price is set, Amount rounds to the same minor units, so that a
+//     product whose Amount was changed after construction is not sent with
+//     its old Price.
+//   - Subscriptions have a positive PeriodLength and a valid PeriodType.
+//   - Fixed products have no period, are not recurring and have no trial.
+//   - A trial is only allowed on a recurring subscription, must itself be a
+//     valid subscription without a trial, and must use the same currency.
+func (p *Product) Validate() error {
+	invalid := func(format string, args ...any) error {
+		return fmt.Errorf("%w %s: %s", ErrInvalidProduct, p.ID, fmt.Sprintf(format, args...))
+	}
+
+	if p.Type != ProductTypeFixed && p.Type != ProductTypeSubscription {
+		return invalid("invalid product type: %s", p.Type)
+	}
+	if p.CurrencyCode != "" {
+		if c, ok := LookupCurrency(p.CurrencyCode); !ok || c.Code != p.CurrencyCode {
+			return invalid("invalid currency code: %q", p.CurrencyCode)
+		}
+	}
+	if err := p.checkPrice(); err != nil {
+		return err
+	}
+
+	if p.Type == ProductTypeSubscription {
+		if p.PeriodLength <= 0 {
+			return invalid("subscription period length must be positive, got %d", p.PeriodLength)
+		}
+		switch p.PeriodType {
+		case PeriodDay, PeriodWeek, PeriodMonth, PeriodYear:
+		default:
+			return invalid("invalid period type: %q", p.PeriodType)
+		}
+	} else {
+		if p.PeriodLength != 0 || p.PeriodType != "" {
+			return invalid("fixed product cannot have a period (got %d %q)", p.PeriodLength, p.PeriodType)
+		}
+		if p.Recurring {
+			return invalid("only subscriptions can be recurring")
+		}
+	}
+
+	if t := p.TrialProduct; t != nil {
+		if p.Type != ProductTypeSubscription || !p.Recurring {
+			return invalid("trial product %s requires a recurring subscription", t.ID)
+		}
+		if t.Type != ProductTypeSubscription {
+			return invalid("trial product %s must be a subscription", t.ID)
+		}
+		if t.TrialProduct != nil {
+			return invalid("trial product %s cannot have its own trial", t.ID)
+		}
+		if t.CurrencyCode != p.CurrencyCode {
+			return invalid("trial currency %q does not match currency %q", t.CurrencyCode, p.CurrencyCode)
+		}
+		if err := t.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // IsRecurring returns true if the product is a recurring subscription.
 func (p *Product) IsRecurring() bool {
 	return p.Recurring
 }
+
+// checkPrice reports an error if Price is set but disagrees with CurrencyCode
+// or Amount.
+func (p *Product) checkPrice() error {
+	if p.Price == (Money{}) {
+		return nil
+	}
+	if p.Price.Currency != p.CurrencyCode {
+		return fmt.Errorf("%w %s: price currency %q does not match currency code %q",
+			ErrInvalidProduct, p.ID, p.Price.Currency, p.CurrencyCode)
+	}
+	if amount := MoneyFromFloat(p.Amount, p.CurrencyCode); amount != p.Price {
+		return fmt.Errorf("%w %s: amount %s does not match price %s; set both",
+			ErrInvalidProduct, p.ID, amount, p.Price)
+	}
+	return nil
+}
+
+// GetPrice returns Price, or Amount converted to CurrencyCode if Price is not
+// set, as happens with hand-constructed products. Call Validate to detect an
+// Amount that no longer matches Price.
+func (p *Product) GetPrice() Money {
+	if p.Price != (Money{}) {
+		return p.Price
+	}
+	return MoneyFromFloat(p.Amount, p.CurrencyCode)
+}
+
+// Period is a subscription billing period, e.g. Period{Length: 3, Type: PeriodMonth}.
+type Period struct {
+	Length int    // number of units, must be positive
+	Type   string // unit: day, week, month, year
+}
+
+// ProductOption configures a Product built by NewFixedProduct or NewSubscription.
+type ProductOption func(*Product)
+
+// WithName sets the human-readable product name.
+func WithName(name string) ProductOption {
+	return func(p *Product) { p.Name = name }
+}
+
+// WithRecurring makes a subscription renew automatically.
+func WithRecurring() ProductOption {
+	return func(p *Product) { p.Recurring = true }
+}
+
+// WithTrial sets the trial product, which must itself be a subscription.
+func WithTrial(trial *Product) ProductOption {
+	return func(p *Product) { p.TrialProduct = trial }
+}
+
+// NewFixedProduct constructs a one-time product priced at price.
+func NewFixedProduct(id string, price Money, opts ...ProductOption) (*Product, error) {
+	return buildProduct(id, price, ProductTypeFixed, Period{}, opts)
+}
+
+// NewSubscription constructs a subscription billed every period.
+func NewSubscription(id string, price Money, period Period, opts ...ProductOption) (*Product, error) {
+	return buildProduct(id, price, ProductTypeSubscription, period, opts)
+}
+
+// buildProduct applies opts and validates the result.
+func buildProduct(id string, price Money, prodType string, period Period, opts []ProductOption) (*Product, error) {
+	currency, err := normalizeCurrencyCode(id, price.Currency)
+	if err != nil {
+		return nil, err
+	}
+	price.Currency = currency
+	p := &Product{
+		ID:           id,
+		Amount:       price.Float64(),
+		Price:        price,
+		CurrencyCode: currency,
+		Type:         prodType,
+		PeriodLength: period.Length,
+		PeriodType:   period.Type,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
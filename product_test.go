@@ -2,7 +2,9 @@
 package paymentwall
 
 import (
+	"errors"
 	"math"
+	"strings"
 	"testing"
 )
 
@@ -42,7 +44,7 @@ func TestNewProduct_InvalidPeriodType(t *testing.T) {
 }
 
 func TestNewProduct_SubscriptionWithTrial(t *testing.T) {
-	tr, _ := NewProduct("t", 0.5, "USD", "Trial", ProductTypeFixed, 0, "", false, nil)
+	tr, _ := NewProduct("t", 0.5, "EUR", "Trial", ProductTypeSubscription, 7, PeriodDay, false, nil)
 	p, err := NewProduct("s", 2.0, "EUR", "Sub", ProductTypeSubscription, 1, PeriodMonth, true, tr)
 	if err != nil {
 		t.Fatal(err)
@@ -56,12 +58,120 @@ func TestNewProduct_SubscriptionWithTrial(t *testing.T) {
 }
 
 func TestNewProduct_Sub_NoTrial(t *testing.T) {
-	tr := &Product{ID: "t2"}
-	p, err := NewProduct("s2", 3.0, "USD", "No", ProductTypeSubscription, 1, PeriodWeek, false, tr)
+	// a trial on a non-recurring subscription used to be dropped silently
+	tr := &Product{ID: "t2", Type: ProductTypeSubscription, PeriodLength: 1, PeriodType: PeriodDay, CurrencyCode: "USD"}
+	_, err := NewProduct("s2", 3.0, "USD", "No", ProductTypeSubscription, 1, PeriodWeek, false, tr)
+	if !errors.Is(err, ErrInvalidProduct) {
+		t.Errorf("NewProduct trial on non-recurring = %v; want ErrInvalidProduct", err)
+	}
+}
+
+func TestProduct_Validate(t *testing.T) {
+	trial := &Product{ID: "t", Type: ProductTypeSubscription, PeriodLength: 7, PeriodType: PeriodDay, CurrencyCode: "USD"}
+	cases := []struct {
+		name string
+		p    Product
+		want string // substring of the error, "" for valid
+	}{
+		{"valid subscription", Product{ID: "s", Type: ProductTypeSubscription, PeriodLength: 1, PeriodType: PeriodMonth, Recurring: true, TrialProduct: trial, CurrencyCode: "USD"}, ""},
+		{"zero period", Product{ID: "s", Type: ProductTypeSubscription, PeriodType: PeriodMonth}, "period length must be positive"},
+		{"empty period type", Product{ID: "s", Type: ProductTypeSubscription, PeriodLength: 1}, "invalid period type"},
+		{"period on fixed", Product{ID: "f", Type: ProductTypeFixed, PeriodLength: 1, PeriodType: PeriodMonth}, "fixed product cannot have a period"},
+		{"recurring fixed", Product{ID: "f", Type: ProductTypeFixed, Recurring: true}, "only subscriptions can be recurring"},
+		{"trial on non-recurring", Product{ID: "s", Type: ProductTypeSubscription, PeriodLength: 1, PeriodType: PeriodMonth, TrialProduct: trial, CurrencyCode: "USD"}, "requires a recurring subscription"},
+		{"trial currency", Product{ID: "s", Type: ProductTypeSubscription, PeriodLength: 1, PeriodType: PeriodMonth, Recurring: true, TrialProduct: trial, CurrencyCode: "EUR"}, "trial currency"},
+		{"fixed trial", Product{ID: "s", Type: ProductTypeSubscription, PeriodLength: 1, PeriodType: PeriodMonth, Recurring: true, TrialProduct: &Product{ID: "t", Type: ProductTypeFixed}}, "must be a subscription"},
+		{"lowercase currency", Product{ID: "f", Type: ProductTypeFixed, CurrencyCode: "usd"}, "invalid currency code"},
+	}
+	for _, tc := range cases {
+		err := tc.p.Validate()
+		switch {
+		case tc.want == "" && err != nil:
+			t.Errorf("%s: Validate = %v; want nil", tc.name, err)
+		case tc.want != "" && (err == nil || !strings.Contains(err.Error(), tc.want) || !errors.Is(err, ErrInvalidProduct)):
+			t.Errorf("%s: Validate = %v; want error containing %q", tc.name, err, tc.want)
+		}
+	}
+}
+
+func TestNewProduct_CurrencyExponent(t *testing.T) {
+	p, err := NewProduct("p", 1234.56, "JPY", "Yen", ProductTypeFixed, 0, "", false, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
-	if p.TrialProduct != nil {
-		t.Error("TrialProduct = non-nil; want nil")
+	if p.Amount != 1235 || p.Price != NewMoney(1235, "JPY") {
+		t.Errorf("JPY Amount = %v, Price = %+v; want 1235", p.Amount, p.Price)
+	}
+	hand := &Product{ID: "h", Amount: 12.1, CurrencyCode: "USD"}
+	if got := hand.GetPrice().String(); got != "12.10" {
+		t.Errorf("GetPrice of hand-built product = %s; want 12.10", got)
+	}
+}
+
+func TestProduct_AmountChangedAfterConstruction(t *testing.T) {
+	p, _ := NewProduct("p", 5.0, "USD", "N", ProductTypeFixed, 0, "", false, nil)
+	p.Amount = 7.5
+	if err := p.Validate(); !errors.Is(err, ErrInvalidProduct) || !strings.Contains(err.Error(), "does not match price") {
+		t.Errorf("Validate after changing Amount = %v; want price mismatch", err)
+	}
+	w := NewWidget(NewClient("k", "s", APIGoods), "u", "pw", []*Product{p}, nil)
+	if _, err := w.GetParams(); !errors.Is(err, ErrInvalidProduct) {
+		t.Errorf("GetParams with stale Price = %v; want ErrInvalidProduct", err)
+	}
+	w = NewWidget(NewClient("k", "s", APICart), "u", "w1", []*Product{p}, nil)
+	if _, err := w.GetParams(); !errors.Is(err, ErrInvalidProduct) {
+		t.Errorf("cart GetParams with stale Price = %v; want ErrInvalidProduct", err)
+	}
+
+	p.Price = MoneyFromFloat(7.5, "USD")
+	if err := p.Validate(); err != nil {
+		t.Errorf("Validate with both updated = %v", err)
+	}
+}
+
+func TestNewFixedProduct(t *testing.T) {
+	p, err := NewFixedProduct("gems", NewMoney(499, "usd"), WithName("Gems"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Type != ProductTypeFixed || p.Name != "Gems" || p.CurrencyCode != "USD" || p.Amount != 4.99 {
+		t.Errorf("NewFixedProduct = %+v", p)
+	}
+	if _, err := NewFixedProduct("gems", NewMoney(499, "USD"), WithRecurring()); err == nil {
+		t.Error("expected error on recurring fixed product")
+	}
+	if _, err := NewFixedProduct("gems", NewMoney(499, "XXX")); !errors.Is(err, ErrInvalidProduct) {
+		t.Errorf("NewFixedProduct invalid currency = %v; want ErrInvalidProduct", err)
+	}
+	if _, err := NewProduct("gems", 4.99, "USDD", "Gems", ProductTypeFixed, 0, "", false, nil); !errors.Is(err, ErrInvalidProduct) {
+		t.Errorf("NewProduct invalid currency = %v; want ErrInvalidProduct", err)
+	}
+}
+
+func TestNewSubscription(t *testing.T) {
+	trial, err := NewSubscription("trial", NewMoney(99, "USD"), Period{Length: 7, Type: PeriodDay})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := NewSubscription("pro", NewMoney(999, "USD"), Period{Length: 1, Type: PeriodMonth},
+		WithName("Pro"), WithRecurring(), WithTrial(trial))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !p.IsRecurring() || p.TrialProduct != trial || p.PeriodLength != 1 || p.PeriodType != PeriodMonth {
+		t.Errorf("NewSubscription = %+v", p)
+	}
+
+	if _, err := NewSubscription("s", NewMoney(1, "USD"), Period{Length: 0, Type: PeriodMonth}); err == nil {
+		t.Error("expected error on zero period length")
+	}
+	if _, err := NewSubscription("s", NewMoney(1, "USD"), Period{Length: 1, Type: "fortnight"}); err == nil {
+		t.Error("expected error on invalid period type")
+	}
+	fixedTrial, _ := NewFixedProduct("t", NewMoney(99, "USD"))
+	_, err = NewSubscription("s", NewMoney(1, "USD"), Period{Length: 1, Type: PeriodMonth},
+		WithRecurring(), WithTrial(fixedTrial))
+	if err == nil {
+		t.Error("expected error on fixed trial product")
 	}
 }
@@ -0,0 +1,107 @@
+// Package paymentwall provides a Go SDK for interacting with the Paymentwall APIs.
+package paymentwall
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+// TrustedProxies is a set of proxy networks, such as load balancers, whose
+// forwarding header is trusted when determining the source IP of a pingback.
+type TrustedProxies struct {
+	// Header is the forwarding header the proxies set, such as "Forwarded",
+	// "X-Forwarded-For" or "X-Real-IP"; empty means X-Forwarded-For. Only
+	// this header is read: others may be passed through unchanged from the
+	// client and cannot be trusted. Set it on the value returned by
+	// NewTrustedProxies; a TrustedProxies built directly trusts no proxies.
+	Header string
+
+	list *IPAllowlist
+}
+
+// NewTrustedProxies builds a TrustedProxies from single addresses and CIDR
+// ranges, in the same format as NewIPAllowlist. It reads X-Forwarded-For
+// unless Header is changed.
+func NewTrustedProxies(entries ...string) (*TrustedProxies, error) {
+	list, err := NewIPAllowlist(entries...)
+	if err != nil {
+		return nil, err
+	}
+	return &TrustedProxies{list: list}, nil
+}
+
+// ClientIP returns the source IP of r. If r.RemoteAddr is a trusted proxy, the
+// forwarding chain in Header is walked right-to-left and the first untrusted
+// hop is returned. The RFC 7239 Forwarded header is parsed for its "for"
+// addresses; any other header is read as a comma-separated list of addresses,
+// which also covers single-address headers like X-Real-IP. Headers are ignored
+// when r.RemoteAddr is not trusted, so clients cannot spoof their address. A
+// nil TrustedProxies, or one not built by NewTrustedProxies, always returns
+// the host part of r.RemoteAddr.
+func (t *TrustedProxies) ClientIP(r *http.Request) string {
+	remote := remoteIP(r)
+	if t == nil || t.list == nil || !t.list.Contains(remote) {
+		return remote
+	}
+
+	header := t.Header
+	if header == "" {
+		header = "X-Forwarded-For"
+	}
+	var hops []string
+	if http.CanonicalHeaderKey(header) == "Forwarded" {
+		hops = forwardedFor(r.Header)
+	} else {
+		for _, hop := range splitHeaderList(r.Header.Values(header)) {
+			hops = append(hops, stripPort(hop))
+		}
+	}
+	if len(hops) == 0 {
+		return remote
+	}
+	for i := len(hops) - 1; i >= 0; i-- {
+		if !t.list.Contains(hops[i]) {
+			return hops[i]
+		}
+	}
+	// every hop is a trusted proxy; the leftmost one is the closest to the origin
+	return hops[0]
+}
+
+// forwardedFor returns the "for" addresses of the RFC 7239 Forwarded header.
+func forwardedFor(h http.Header) []string {
+	var hops []string
+	for _, elem := range splitHeaderList(h.Values("Forwarded")) {
+		for _, pair := range strings.Split(elem, ";") {
+			k, v, ok := strings.Cut(strings.TrimSpace(pair), "=")
+			if !ok || !strings.EqualFold(k, "for") {
+				continue
+			}
+			hops = append(hops, stripPort(strings.Trim(v, `"`)))
+		}
+	}
+	return hops
+}
+
+// splitHeaderList splits comma-separated header values into trimmed elements.
+func splitHeaderList(values []string) []string {
+	var out []string
+	for _, v := range values {
+		for _, part := range strings.Split(v, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				out = append(out, part)
+			}
+		}
+	}
+	return out
+}
+
+// stripPort removes an optional port and IPv6 brackets from an address.
+func stripPort(addr string) string {
+	addr = strings.TrimSpace(addr)
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(addr, "["), "]")
+}
@@ -0,0 +1,117 @@
+// proxy_test.go
+package paymentwall
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTrustedProxies_ClientIP(t *testing.T) {
+	tp, err := NewTrustedProxies("10.0.0.0/8", "fd00::/8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		name   string
+		use    string // TrustedProxies.Header
+		remote string
+		header map[string]string
+		want   string
+	}{
+		{"untrusted remote ignores headers", "", "203.0.113.9:1000",
+			map[string]string{"X-Forwarded-For": "174.36.92.186"}, "203.0.113.9"},
+		{"x-forwarded-for rightmost untrusted", "", "10.0.0.1:1000",
+			map[string]string{"X-Forwarded-For": "1.1.1.1, 174.36.92.186, 10.0.0.7"}, "174.36.92.186"},
+		{"forwarded header when configured", "Forwarded", "10.0.0.1:1000",
+			map[string]string{
+				"Forwarded":       `for=1.1.1.1, for="174.36.92.187:4711";proto=https, for="[fd00::1]"`,
+				"X-Forwarded-For": "9.9.9.9",
+			}, "174.36.92.187"},
+		{"forwarded ipv6", "forwarded", "[fd00::2]:443",
+			map[string]string{"Forwarded": `For="[2001:db8:cafe::17]:4711"`}, "2001:db8:cafe::17"},
+		{"x-real-ip when configured", "X-Real-IP", "10.0.0.1:1000",
+			map[string]string{"X-Real-IP": "174.36.96.66", "X-Forwarded-For": "9.9.9.9"}, "174.36.96.66"},
+		{"other headers ignored", "", "10.0.0.1:1000",
+			map[string]string{"X-Real-IP": "174.36.96.66"}, "10.0.0.1"},
+		{"all hops trusted", "", "10.0.0.1:1000",
+			map[string]string{"X-Forwarded-For": "10.1.1.1, 10.2.2.2"}, "10.1.1.1"},
+		{"no headers", "", "10.0.0.1:1000", nil, "10.0.0.1"},
+	}
+	for _, tc := range cases {
+		tp.Header = tc.use
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = tc.remote
+		for k, v := range tc.header {
+			req.Header.Set(k, v)
+		}
+		if got := tp.ClientIP(req); got != tc.want {
+			t.Errorf("%s: ClientIP = %q; want %q", tc.name, got, tc.want)
+		}
+	}
+
+	var none *TrustedProxies
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "10.0.0.1:1000"
+	req.Header.Set("X-Forwarded-For", "174.36.92.186")
+	if got := none.ClientIP(req); got != "10.0.0.1" {
+		t.Errorf("nil TrustedProxies ClientIP = %q; want 10.0.0.1", got)
+	}
+}
+
+func TestPingbackHandler_BehindProxy(t *testing.T) {
+	cl := NewClient("k", "s", APIGoods)
+	cl.TrustedProxies, _ = NewTrustedProxies("10.0.0.0/8")
+	h := NewPingbackHandler(cl)
+
+	req := httptest.NewRequest(http.MethodGet, "/pingback?"+signedPingbackValues(t, cl, "0").Encode(), nil)
+	req.RemoteAddr = "10.0.0.5:80"
+	req.Header.Set("X-Forwarded-For", "174.36.92.186")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Body.String() != "OK" {
+		t.Errorf("pingback via trusted proxy = %d %q; want OK", rec.Code, rec.Body.String())
+	}
+
+	// spoofed header from an untrusted peer
+	req = httptest.NewRequest(http.MethodGet, "/pingback?"+signedPingbackValues(t, cl, "0").Encode(), nil)
+	req.RemoteAddr = "8.8.8.8:80"
+	req.Header.Set("X-Forwarded-For", "174.36.92.186")
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("spoofed X-Forwarded-For: code = %d; want %d", rec.Code, http.StatusForbidden)
+	}
+}
+
+func TestTrustedProxies_PassedThroughForwardedIgnored(t *testing.T) {
+	// the balancer appends to X-Forwarded-For but passes the client's own
+	// Forwarded header through unchanged
+	cl := NewClient("k", "s", APIGoods)
+	cl.TrustedProxies, _ = NewTrustedProxies("10.0.0.0/8")
+	h := NewPingbackHandler(cl)
+
+	req := httptest.NewRequest(http.MethodGet, "/pingback?"+signedPingbackValues(t, cl, "0").Encode(), nil)
+	req.RemoteAddr = "10.0.0.5:80"
+	req.Header.Set("X-Forwarded-For", "8.8.8.8")
+	req.Header.Set("Forwarded", "for=174.36.92.186")
+	if got := cl.TrustedProxies.ClientIP(req); got != "8.8.8.8" {
+		t.Errorf("ClientIP = %q; want 8.8.8.8", got)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("spoofed Forwarded: code = %d; want %d", rec.Code, http.StatusForbidden)
+	}
+}
+
+func TestTrustedProxies_ZeroValue(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/pingback", nil)
+	req.RemoteAddr = "10.0.0.5:80"
+	req.Header.Set("X-Real-IP", "174.36.92.186")
+	for _, tp := range []*TrustedProxies{nil, {}, {Header: "X-Real-IP"}} {
+		if got := tp.ClientIP(req); got != "10.0.0.5" {
+			t.Errorf("%+v: ClientIP = %q; want 10.0.0.5", tp, got)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+// Package paymentwall provides a Go SDK for interacting with the Paymentwall APIs.
+package paymentwall
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// CancellationReason is the value of the "reason" parameter sent with
+// cancellation pingbacks (type 2 and 202).
+type CancellationReason int
+
+const (
+	ReasonChargeback                   CancellationReason = 1
+	ReasonCreditCardFraud              CancellationReason = 2
+	ReasonOrderFraud                   CancellationReason = 3
+	ReasonBadDataEntry                 CancellationReason = 4
+	ReasonFakeProxyUser                CancellationReason = 5
+	ReasonRejectedByAdvertiser         CancellationReason = 6
+	ReasonDuplicateConversions         CancellationReason = 7
+	ReasonGoodwillCreditTakenBack      CancellationReason = 8
+	ReasonCancelledOrder               CancellationReason = 9
+	ReasonPartiallyReversedTransaction CancellationReason = 10
+)
+
+// cancellationReasons maps each documented reason to its name and description.
+var cancellationReasons = map[CancellationReason][2]string{
+	ReasonChargeback:                   {"chargeback", "Chargeback"},
+	ReasonCreditCardFraud:              {"credit_card_fraud", "Credit card fraud"},
+	ReasonOrderFraud:                   {"order_fraud", "Order fraud"},
+	ReasonBadDataEntry:                 {"bad_data_entry", "Bad data entry"},
+	ReasonFakeProxyUser:                {"fake_proxy_user", "Fake / proxy user"},
+	ReasonRejectedByAdvertiser:         {"rejected_by_advertiser", "Rejected by advertiser"},
+	ReasonDuplicateConversions:         {"duplicate_conversions", "Duplicate conversions"},
+	ReasonGoodwillCreditTakenBack:      {"goodwill_credit_taken_back", "Goodwill credit taken back"},
+	ReasonCancelledOrder:               {"cancelled_order", "Cancelled order"},
+	ReasonPartiallyReversedTransaction: {"partially_reversed_transaction", "Partially reversed transaction"},
+}
+
+// String returns a stable identifier such as "chargeback", suitable for
+// metrics and dashboards, or "unknown_N" for undocumented reasons.
+func (r CancellationReason) String() string {
+	if names, ok := cancellationReasons[r]; ok {
+		return names[0]
+	}
+	return fmt.Sprintf("unknown_%d", int(r))
+}
+
+// Description returns a human-readable description of the reason.
+func (r CancellationReason) Description() string {
+	if names, ok := cancellationReasons[r]; ok {
+		return names[1]
+	}
+	return fmt.Sprintf("Unknown reason (%d)", int(r))
+}
+
+// Known reports whether r is one of the documented reasons.
+func (r CancellationReason) Known() bool {
+	_, ok := cancellationReasons[r]
+	return ok
+}
+
+// GetReason returns the cancellation reason. It returns a *MissingParamError
+// if the pingback has no reason, and an *InvalidParamError if it is not a
+// number. Undocumented numeric reasons are returned as is; check Known.
+func (p *Pingback) GetReason() (CancellationReason, error) {
+	s, ok := p.optionalParam("reason")
+	if !ok {
+		return 0, &MissingParamError{Name: "reason"}
+	}
+	r, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, &InvalidParamError{Name: "reason", Value: s}
+	}
+	return CancellationReason(r), nil
+}
@@ -0,0 +1,41 @@
+// reason_test.go
+package paymentwall
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPingback_GetReason(t *testing.T) {
+	cl := NewClient("k", "s", APIGoods)
+	pb := NewPingback(cl, map[string]any{"type": "2", "reason": "2"}, "")
+	r, err := pb.GetReason()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r != ReasonCreditCardFraud || r.String() != "credit_card_fraud" || r.Description() != "Credit card fraud" {
+		t.Errorf("GetReason = %v (%s)", r, r.Description())
+	}
+
+	pb = NewPingback(cl, map[string]any{"type": "2", "reason": "42"}, "")
+	if r, err := pb.GetReason(); err != nil || r.Known() || r.String() != "unknown_42" {
+		t.Errorf("GetReason undocumented = %v, %v", r, err)
+	}
+
+	pb = NewPingback(cl, map[string]any{"type": "2"}, "")
+	if _, err := pb.GetReason(); !errors.Is(err, ErrMissingParam) {
+		t.Errorf("GetReason absent = %v; want ErrMissingParam", err)
+	}
+	pb = NewPingback(cl, map[string]any{"type": "2", "reason": "fraud"}, "")
+	if _, err := pb.GetReason(); !errors.Is(err, ErrInvalidParam) {
+		t.Errorf("GetReason garbage = %v; want ErrInvalidParam", err)
+	}
+}
+
+func TestCancellationReason_AllDocumented(t *testing.T) {
+	for r := ReasonChargeback; r <= ReasonPartiallyReversedTransaction; r++ {
+		if !r.Known() || r.Description() == "" {
+			t.Errorf("reason %d is not documented", r)
+		}
+	}
+}
@@ -4,19 +4,30 @@ package paymentwall
 import (
 	"fmt"
 	"html"
-	"net/url"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 )
 
 // Widget builds Paymentwall widget URLs and HTML.
 type Widget struct {
-	Client      *Client
-	UserID      string
-	WidgetCode  string
-	Products    []*Product
+	Client     *Client
+	UserID     string
+	WidgetCode string
+	Products   []*Product
+	// Options holds the documented optional parameters; nil means none.
+	Options *WidgetOptions
+	// Customer and History, if set, are sent as customer[...] and
+	// history[...] parameters for risk scoring.
+	Customer *CustomerProfile
+	History  *UserHistory
+	// ExtraParams holds any other parameters, such as undocumented ones.
+	// Keys the widget sets itself are rejected; see ReservedWidgetParams.
 	ExtraParams map[string]any
+	// Strict rejects ExtraParams keys that are not documented widget
+	// parameters, so that typos are not silently signed and sent.
+	Strict bool
 }
 
 // NewWidget initializes a new Widget.
@@ -53,12 +64,19 @@ func (w *Widget) GetParams() (map[string]any, error) {
 	case APIGoods:
 		// Expect exactly one product
 		if len(w.Products) > 1 {
-			w.Client.AppendError("only one product allowed for API Checkout and empty products for API Goods")
-			return params, fmt.Errorf("invalid product count: %d", len(w.Products))
+			return params, fmt.Errorf("invalid product count: %d: only one product allowed for API Checkout and empty products for API Goods", len(w.Products))
 		}
 
 		if len(w.Products) == 1 {
 			prod := w.Products[0]
+			if err := prod.checkPrice(); err != nil {
+				return params, err
+			}
+			if t := prod.TrialProduct; t != nil {
+				if err := t.checkPrice(); err != nil {
+					return params, err
+				}
+			}
 			var postTrialProduct *Product
 			if prod.TrialProduct != nil {
 				postTrialProduct = prod
@@ -66,7 +84,7 @@ func (w *Widget) GetParams() (map[string]any, error) {
 			}
 
 			// Basic product fields
-			params["amount"] = prod.Amount
+			params["amount"] = prod.GetPrice().String()
 			params["currencyCode"] = prod.CurrencyCode
 			params["ag_name"] = prod.Name
 			params["ag_external_id"] = prod.ID
@@ -89,7 +107,7 @@ func (w *Widget) GetParams() (map[string]any, error) {
 					params["ag_post_trial_period_length"] = postTrialProduct.PeriodLength
 					params["ag_post_trial_period_type"] = postTrialProduct.PeriodType
 					params["ag_post_trial_name"] = postTrialProduct.Name
-					params["post_trial_amount"] = postTrialProduct.Amount
+					params["post_trial_amount"] = postTrialProduct.GetPrice().String()
 					params["post_trial_currencyCode"] = postTrialProduct.CurrencyCode
 				}
 			}
@@ -97,9 +115,12 @@ func (w *Widget) GetParams() (map[string]any, error) {
 	case APICart:
 		// Multiple products
 		for i, prod := range w.Products {
+			if err := prod.checkPrice(); err != nil {
+				return params, err
+			}
 			params[fmt.Sprintf("external_ids[%d]", i)] = prod.ID
-			if prod.Amount > 0 {
-				params[fmt.Sprintf("prices[%d]", i)] = prod.Amount
+			if price := prod.GetPrice(); price.Minor > 0 {
+				params[fmt.Sprintf("prices[%d]", i)] = price.String()
 			}
 			if prod.CurrencyCode != "" {
 				params[fmt.Sprintf("currencies[%d]", i)] = prod.CurrencyCode
@@ -109,9 +130,31 @@ func (w *Widget) GetParams() (map[string]any, error) {
 		// APIVC: no product fields
 	}
 
+	// Typed options
+	if w.Options != nil {
+		if err := w.Options.Validate(); err != nil {
+			return params, err
+		}
+		for k, v := range w.Options.params() {
+			params[k] = v
+		}
+	}
+
+	// Risk scoring profile
+	if w.Customer != nil {
+		for k, v := range w.Customer.params() {
+			params[k] = v
+		}
+	}
+	if w.History != nil {
+		for k, v := range w.History.params() {
+			params[k] = v
+		}
+	}
+
 	// Merge extra params
-	for k, v := range w.ExtraParams {
-		params[k] = v
+	if err := w.mergeExtraParams(params); err != nil {
+		return params, err
 	}
 
 	// Determine signature version
@@ -137,6 +180,85 @@ func (w *Widget) GetParams() (map[string]any, error) {
 	return params, nil
 }
 
+// Parameters set by GetParams itself, by API type. Names are matched by
+// their base, so "prices" covers "prices[0]".
+var (
+	commonReservedParams = []string{"key", "uid", "widget", "sign"}
+	goodsReservedParams  = []string{
+		"amount", "currencyCode", "ag_name", "ag_external_id", "ag_type",
+		"ag_period_length", "ag_period_type", "ag_recurring", "ag_trial",
+		"ag_post_trial_external_id", "ag_post_trial_period_length", "ag_post_trial_period_type",
+		"ag_post_trial_name", "post_trial_amount", "post_trial_currencyCode",
+	}
+	cartReservedParams = []string{"external_ids", "prices", "currencies"}
+)
+
+// documentedWidgetParams are the optional widget parameters accepted in
+// ExtraParams when Widget.Strict is set.
+var documentedWidgetParams = codeSet(`
+email success_url failure_url pingback_url lang country_code ps evaluation ts
+sign_version customer history
+`)
+
+// ReservedWidgetParams returns the parameter names that the widget sets
+// itself for api and that ExtraParams therefore cannot contain.
+func ReservedWidgetParams(api APIType) []string {
+	names := append([]string(nil), commonReservedParams...)
+	switch api {
+	case APIGoods:
+		names = append(names, goodsReservedParams...)
+	case APICart:
+		names = append(names, cartReservedParams...)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// paramBaseName returns the name before any bracketed index or key, e.g.
+// "prices" for "prices[0]".
+func paramBaseName(name string) string {
+	if i := strings.IndexByte(name, '['); i > 0 {
+		return name[:i]
+	}
+	return name
+}
+
+// mergeExtraParams adds ExtraParams to params. Extra values are flattened
+// the way they are signed, and any resulting key that is reserved for the API
+// type or already set, e.g. by Options or Customer, is a *ReservedParamError;
+// in strict mode undocumented keys are an *UnknownParamError.
+func (w *Widget) mergeExtraParams(params map[string]any) error {
+	reserved := make(map[string]bool)
+	for _, name := range ReservedWidgetParams(w.Client.APIType) {
+		reserved[name] = true
+	}
+	set := make(map[string]bool)
+	for _, p := range encodeParams(NewOrderedParams(params), false) {
+		set[p.key] = true
+	}
+	keys := make([]string, 0, len(w.ExtraParams))
+	for k := range w.ExtraParams {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if reserved[paramBaseName(k)] {
+			return &ReservedParamError{Name: k}
+		}
+		if w.Strict && !documentedWidgetParams[paramBaseName(k)] {
+			return &UnknownParamError{Name: k}
+		}
+		for _, p := range appendEncoded(nil, k, w.ExtraParams[k]) {
+			if set[p.key] {
+				return &ReservedParamError{Name: p.key}
+			}
+			set[p.key] = true
+		}
+		params[k] = w.ExtraParams[k]
+	}
+	return nil
+}
+
 // GetURL builds the full widget URL.
 func (w *Widget) GetURL() (string, error) {
 	params, err := w.GetParams()
@@ -144,11 +266,7 @@ func (w *Widget) GetURL() (string, error) {
 		return "", err
 	}
 	controller := w.buildController(w.WidgetCode)
-	vals := url.Values{}
-	for k, v := range params {
-		vals.Set(k, fmt.Sprint(v))
-	}
-	return fmt.Sprintf("%s/%s?%s", BaseURL, controller, vals.Encode()), nil
+	return fmt.Sprintf("%s/%s?%s", BaseURL, controller, encodeQuery(params)), nil
 }
 
 // GetHTMLCode returns the iframe HTML code for the widget.
@@ -176,18 +294,20 @@ func (w *Widget) GetHTMLCode(attrs map[string]string) (string, error) {
 	return fmt.Sprintf(`<iframe src="%s" %s></iframe>`, iframeURL, strings.Join(parts, " ")), nil
 }
 
+// widgetCodePattern matches widget codes served by the cart controller.
+var widgetCodePattern = regexp.MustCompile(`^(w|s|mw)`)
+
 // buildController selects the appropriate controller path.
 func (w *Widget) buildController(code string) string {
-	pattern := regexp.MustCompile(`^(w|s|mw)`)
 	switch w.Client.APIType {
 	case APIVC:
-		if !pattern.MatchString(code) {
+		if !widgetCodePattern.MatchString(code) {
 			return VCController
 		}
 	case APIGoods:
-		if !pattern.MatchString(code) {
+		if !widgetCodePattern.MatchString(code) {
 			return GoodsController
 		}
 	}
 	return CartController
-}
\ No newline at end of file
+}
@@ -2,6 +2,7 @@
 package paymentwall
 
 import (
+	"errors"
 	"net/url"
 	"strings"
 	"testing"
@@ -32,3 +33,86 @@ func TestWidget_GetURL_HTMLCode(t *testing.T) {
 		t.Errorf("HTMLCode did not escape: %s", iframe)
 	}
 }
+
+func TestWidget_GetParams_CanonicalAmount(t *testing.T) {
+	client := NewClient("k", "s", APIGoods)
+	prod, _ := NewProduct("p", 12.1, "USD", "N", ProductTypeFixed, 0, "", false, nil)
+	w := NewWidget(client, "u", "pw", []*Product{prod}, nil)
+	params, err := w.GetParams()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if params["amount"] != "12.10" {
+		t.Errorf("amount = %v; want 12.10", params["amount"])
+	}
+	urlStr, _ := w.GetURL()
+	if !strings.Contains(urlStr, "amount=12.10") {
+		t.Errorf("GetURL = %s; want amount=12.10", urlStr)
+	}
+
+	cart := NewClient("k", "s", APICart)
+	kwd, _ := NewProduct("k1", 1.5, "KWD", "K", ProductTypeFixed, 0, "", false, nil)
+	params, _ = NewWidget(cart, "u", "w1", []*Product{kwd}, nil).GetParams()
+	if params["prices[0]"] != "1.500" {
+		t.Errorf("prices[0] = %v; want 1.500", params["prices[0]"])
+	}
+}
+
+func TestWidget_ExtraParamsCannotOverrideReserved(t *testing.T) {
+	prod, _ := NewProduct("p", 5.0, "USD", "N", ProductTypeFixed, 0, "", false, nil)
+	cases := []struct {
+		api   APIType
+		prods []*Product
+		key   string
+	}{
+		{APIVC, nil, "key"},
+		{APIVC, nil, "uid"},
+		{APIVC, nil, "sign"},
+		{APIGoods, []*Product{prod}, "amount"},
+		{APIGoods, nil, "ag_external_id"},
+		{APICart, []*Product{prod}, "prices[0]"},
+		{APICart, nil, "external_ids[3]"},
+	}
+	for _, tc := range cases {
+		w := NewWidget(NewClient("k", "s", tc.api), "u", "p1", tc.prods, map[string]any{tc.key: "evil"})
+		_, err := w.GetParams()
+		var rpe *ReservedParamError
+		if !errors.As(err, &rpe) || rpe.Name != tc.key || !errors.Is(err, ErrReservedParam) {
+			t.Errorf("api %d ExtraParams[%s]: GetParams = %v; want *ReservedParamError", tc.api, tc.key, err)
+		}
+	}
+
+	// keys produced by typed options collide too
+	w := NewWidget(NewClient("k", "s", APIVC), "u", "p1", nil, map[string]any{"email": "b@example.com"})
+	w.Options = &WidgetOptions{Email: "a@example.com"}
+	if _, err := w.GetParams(); !errors.Is(err, ErrReservedParam) {
+		t.Errorf("ExtraParams overriding Options: GetParams = %v; want ErrReservedParam", err)
+	}
+
+	// amount is only reserved for the Goods API
+	w = NewWidget(NewClient("k", "s", APIVC), "u", "p1", nil, map[string]any{"amount": "1"})
+	if _, err := w.GetParams(); err != nil {
+		t.Errorf("VC ExtraParams[amount]: GetParams = %v; want nil", err)
+	}
+}
+
+func TestWidget_Strict(t *testing.T) {
+	w := NewWidget(NewClient("k", "s", APIVC), "u", "p1", nil, map[string]any{"emial": "a@example.com"})
+	if _, err := w.GetParams(); err != nil {
+		t.Fatalf("non-strict GetParams = %v", err)
+	}
+	w.Strict = true
+	_, err := w.GetParams()
+	var upe *UnknownParamError
+	if !errors.As(err, &upe) || upe.Name != "emial" || !errors.Is(err, ErrUnknownParam) {
+		t.Errorf("strict GetParams = %v; want *UnknownParamError{emial}", err)
+	}
+	w.ExtraParams = map[string]any{"email": "a@example.com", "sign_version": 2}
+	params, err := w.GetParams()
+	if err != nil {
+		t.Fatalf("strict GetParams documented keys = %v", err)
+	}
+	if params["sign_version"] != 2 {
+		t.Errorf("sign_version = %v; want 2", params["sign_version"])
+	}
+}
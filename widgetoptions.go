@@ -0,0 +1,79 @@
+// Package paymentwall provides a Go SDK for interacting with the Paymentwall APIs.
+package paymentwall
+
+import (
+	"net/mail"
+	"net/url"
+	"time"
+)
+
+// WidgetOptions holds the documented optional widget parameters. Empty fields
+// are not sent. Parameters without a field can still be passed through
+// Widget.ExtraParams.
+type WidgetOptions struct {
+	Email       string    // email: end-user's email address
+	SuccessURL  string    // success_url: redirect after a successful payment
+	FailureURL  string    // failure_url: redirect after a failed payment
+	PingbackURL string    // pingback_url: overrides the project's pingback URL
+	Lang        string    // lang: ISO 639-1 language code, e.g. "en"
+	CountryCode string    // country_code: ISO 3166-1 alpha-2 code, e.g. "US"
+	PS          string    // ps: payment system shortcode, e.g. "cc"
+	Evaluation  bool      // evaluation: test mode for the project
+	Timestamp   time.Time // ts: request time, sent as Unix seconds
+}
+
+// Validate checks the email address, URLs, language and country. Errors are
+// *InvalidParamError named after the widget parameter.
+func (o *WidgetOptions) Validate() error {
+	if o.Email != "" {
+		if a, err := mail.ParseAddress(o.Email); err != nil || a.Address != o.Email {
+			return &InvalidParamError{Name: "email", Value: o.Email}
+		}
+	}
+	for _, u := range []struct{ name, value string }{
+		{"success_url", o.SuccessURL},
+		{"failure_url", o.FailureURL},
+		{"pingback_url", o.PingbackURL},
+	} {
+		if u.value != "" && !isAbsoluteHTTPURL(u.value) {
+			return &InvalidParamError{Name: u.name, Value: u.value}
+		}
+	}
+	if o.Lang != "" && !IsLanguageCode(o.Lang) {
+		return &InvalidParamError{Name: "lang", Value: o.Lang}
+	}
+	if o.CountryCode != "" && !IsCountryCode(o.CountryCode) {
+		return &InvalidParamError{Name: "country_code", Value: o.CountryCode}
+	}
+	return nil
+}
+
+// params returns the non-empty options as widget parameters.
+func (o *WidgetOptions) params() map[string]any {
+	params := make(map[string]any)
+	set := func(k, v string) {
+		if v != "" {
+			params[k] = v
+		}
+	}
+	set("email", o.Email)
+	set("success_url", o.SuccessURL)
+	set("failure_url", o.FailureURL)
+	set("pingback_url", o.PingbackURL)
+	set("lang", o.Lang)
+	set("country_code", o.CountryCode)
+	set("ps", o.PS)
+	if o.Evaluation {
+		params["evaluation"] = 1
+	}
+	if !o.Timestamp.IsZero() {
+		params["ts"] = o.Timestamp.Unix()
+	}
+	return params
+}
+
+// isAbsoluteHTTPURL reports whether s is an absolute http or https URL.
+func isAbsoluteHTTPURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
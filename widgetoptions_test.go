@@ -0,0 +1,83 @@
+// widgetoptions_test.go
+package paymentwall
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWidgetOptions_Params(t *testing.T) {
+	client := NewClient("k", "s", APIVC)
+	w := NewWidget(client, "u", "p1", nil, map[string]any{"custom_field": "x"})
+	w.Options = &WidgetOptions{
+		Email:       "user@example.com",
+		SuccessURL:  "https://example.com/ok",
+		Lang:        "en",
+		CountryCode: "US",
+		PS:          "cc",
+		Evaluation:  true,
+		Timestamp:   time.Unix(1700000000, 0),
+	}
+	params, err := w.GetParams()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{
+		"email":        "user@example.com",
+		"success_url":  "https://example.com/ok",
+		"lang":         "en",
+		"country_code": "US",
+		"ps":           "cc",
+		"evaluation":   1,
+		"ts":           int64(1700000000),
+		"custom_field": "x",
+	}
+	for k, v := range want {
+		if params[k] != v {
+			t.Errorf("params[%s] = %v (%T); want %v (%T)", k, params[k], params[k], v, v)
+		}
+	}
+	for _, k := range []string{"failure_url", "pingback_url"} {
+		if _, ok := params[k]; ok {
+			t.Errorf("params[%s] set for empty option", k)
+		}
+	}
+	if err := client.VerifySignature(params, params["sign"].(string), SigV3); err != nil {
+		t.Errorf("signature does not cover options: %v", err)
+	}
+}
+
+func TestWidgetOptions_Validate(t *testing.T) {
+	cases := []struct {
+		opts WidgetOptions
+		name string // invalid parameter, "" if valid
+	}{
+		{WidgetOptions{}, ""},
+		{WidgetOptions{Email: "a@b.co", PingbackURL: "http://h.example/pb", Lang: "PT", CountryCode: "de"}, ""},
+		{WidgetOptions{Email: "not an email"}, "email"},
+		{WidgetOptions{Email: "Name <a@b.co>"}, "email"},
+		{WidgetOptions{SuccessURL: "/relative"}, "success_url"},
+		{WidgetOptions{FailureURL: "ftp://h.example/x"}, "failure_url"},
+		{WidgetOptions{PingbackURL: "https://"}, "pingback_url"},
+		{WidgetOptions{Lang: "english"}, "lang"},
+		{WidgetOptions{Lang: "xx"}, "lang"},
+		{WidgetOptions{CountryCode: "UK"}, "country_code"},
+	}
+	for _, tc := range cases {
+		err := tc.opts.Validate()
+		var ipe *InvalidParamError
+		switch {
+		case tc.name == "" && err != nil:
+			t.Errorf("Validate(%+v) = %v; want nil", tc.opts, err)
+		case tc.name != "" && (!errors.As(err, &ipe) || ipe.Name != tc.name):
+			t.Errorf("Validate(%+v) = %v; want invalid %s", tc.opts, err, tc.name)
+		}
+	}
+
+	w := NewWidget(NewClient("k", "s", APIVC), "u", "p1", nil, nil)
+	w.Options = &WidgetOptions{CountryCode: "ZZ"}
+	if _, err := w.GetParams(); !errors.Is(err, ErrInvalidParam) {
+		t.Errorf("GetParams invalid options = %v; want ErrInvalidParam", err)
+	}
+}